@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Node types of the Argument Interchange Format (AIF), as used by AIFdb and OVA
+const AIF_NODE_I = "I"   // Information: a statement
+const AIF_NODE_RA = "RA" // Rule of inference Application: support
+const AIF_NODE_CA = "CA" // Conflict Application: attack
+const AIF_NODE_MA = "MA" // Rephrase
+const AIF_NODE_PA = "PA" // Preference
+const AIF_NODE_L = "L"   // Locution
+const AIF_NODE_TA = "TA" // Transition
+const AIF_NODE_YA = "YA" // Illocutionary anchoring
+
+var aifTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// AIFDocument is the AIF-JSON layout, with one list of nodes and one of edges
+type AIFDocument struct {
+	Nodes     []AIFNode         `json:"nodes"`
+	Edges     []AIFEdge         `json:"edges"`
+	Locutions []json.RawMessage `json:"locutions"`
+}
+
+type AIFNode struct {
+	ID        AIFID  `json:"nodeID"`
+	Text      string `json:"text"`
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Scheme    string `json:"scheme,omitempty"`
+}
+
+type AIFEdge struct {
+	ID     AIFID `json:"edgeID"`
+	FromID AIFID `json:"fromID"`
+	ToID   AIFID `json:"toID"`
+}
+
+// AIFID is a node or edge ID. AIFdb writes them as strings, but OVA writes numbers,
+// so both are accepted and normalized to a string.
+type AIFID string
+
+func (id *AIFID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = AIFID(s)
+		return nil
+	}
+	var n json.Number
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&n); err != nil {
+		return fmt.Errorf("AIF ID must be a string or a number, found %s", string(data))
+	}
+	*id = AIFID(n.String())
+	return nil
+}
+
+func (node AIFNode) CreatedAt() int64 {
+	for _, layout := range aifTimeLayouts {
+		if t, err := time.Parse(layout, node.Timestamp); err == nil {
+			return t.UnixNano() / 1000000
+		}
+	}
+	return 0
+}
+
+// IsSchemeNode is true for RA and CA nodes, which become Arguments
+func (node AIFNode) IsSchemeNode() bool {
+	return node.Type == AIF_NODE_RA || node.Type == AIF_NODE_CA
+}
+
+// parseAIF converts an AIF-JSON document into Debate Map nodes, so the rest of the import
+// can treat it like any other export:
+//   - I-nodes become Claims
+//   - RA-nodes become PRO Arguments, and CA-nodes become CON Arguments, children of their conclusion
+//     (which can be a Claim, or another RA/CA node for undercutters)
+//   - An RA/CA-node with a single premise becomes an Argument with that premise as its base Claim
+//   - An RA/CA-node with several premises becomes a multi-premise Argument, which is later converted
+//     into an MP Claim (see DebateMapNode.ConvertToMPClaim) with its premises in edge order
+//
+// Other node types (locutions, rephrases, preferences...) are not part of the Canonical Debate model,
+// and are skipped.
+func parseAIF(data []byte) (DebateMapRoot, error) {
+	root := DebateMapRoot{}
+	doc := AIFDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return root, fmt.Errorf("error parsing AIF JSON: %s", err.Error())
+	}
+
+	aifNodes := map[AIFID]AIFNode{}
+	for _, an := range doc.Nodes {
+		if an.ID == "" {
+			return root, fmt.Errorf("AIF node without a nodeID: %+v", an)
+		}
+		aifNodes[an.ID] = an
+	}
+
+	premises := map[AIFID][]AIFID{}
+	conclusions := map[AIFID][]AIFID{}
+	for _, edge := range doc.Edges {
+		from, ok := aifNodes[edge.FromID]
+		if !ok {
+			return root, fmt.Errorf("AIF edge %s starts at unknown node %s", edge.ID, edge.FromID)
+		}
+		to, ok := aifNodes[edge.ToID]
+		if !ok {
+			return root, fmt.Errorf("AIF edge %s ends at unknown node %s", edge.ID, edge.ToID)
+		}
+		if to.IsSchemeNode() && from.Type == AIF_NODE_I {
+			premises[to.ID] = append(premises[to.ID], from.ID)
+		} else if from.IsSchemeNode() && (to.Type == AIF_NODE_I || to.IsSchemeNode()) {
+			conclusions[from.ID] = append(conclusions[from.ID], to.ID)
+		}
+	}
+
+	nodes := map[AIFID]*DebateMapNode{}
+	order := []AIFID{}
+	skipped := map[string]int{}
+	for _, an := range doc.Nodes {
+		switch an.Type {
+		case AIF_NODE_I:
+			nodes[an.ID] = &DebateMapNode{
+				ID:        string(an.ID),
+				CreatedAt: an.CreatedAt(),
+				Type:      NODE_TYPE_CLAIM,
+				Current: Current{
+					ID:    string(an.ID),
+					Title: TitleSet{Base: an.Text},
+				},
+				Parents:  map[string]interface{}{},
+				Children: map[string]interface{}{},
+			}
+			order = append(order, an.ID)
+		case AIF_NODE_RA, AIF_NODE_CA:
+			if len(conclusions[an.ID]) == 0 {
+				fmt.Fprintf(logOutput, "----------------------------AIF %s-node %s has no conclusion, skipping it\n", an.Type, an.ID)
+				continue
+			}
+			if len(premises[an.ID]) == 0 {
+				fmt.Fprintf(logOutput, "----------------------------AIF %s-node %s has no premises, skipping it\n", an.Type, an.ID)
+				continue
+			}
+			polarity := ARGUMENT_POLARITY_PRO
+			if an.Type == AIF_NODE_CA {
+				polarity = ARGUMENT_POLARITY_CON
+			}
+			node := &DebateMapNode{
+				ID:           string(an.ID),
+				CreatedAt:    an.CreatedAt(),
+				Type:         NODE_TYPE_ARGUMENT,
+				Polarity:     polarity,
+				MultiPremise: len(premises[an.ID]) > 1,
+				Current: Current{
+					ID:           string(an.ID),
+					ArgumentType: ARGUMENT_TYPE_ALL,
+				},
+				Parents:  map[string]interface{}{},
+				Children: map[string]interface{}{},
+			}
+			for _, premiseID := range premises[an.ID] {
+				node.Children[string(premiseID)] = Child{ID: string(premiseID)}
+				node.ChildrenOrder = append(node.ChildrenOrder, string(premiseID))
+			}
+			nodes[an.ID] = node
+			order = append(order, an.ID)
+		default:
+			skipped[an.Type]++
+		}
+	}
+	for nodeType, count := range skipped {
+		fmt.Fprintf(logOutput, "----------------------------Skipped %d AIF %s-nodes\n", count, nodeType)
+	}
+
+	// Hang each Argument under the Claims (or Arguments) it concludes
+	for _, id := range order {
+		node := nodes[id]
+		if node.Type != NODE_TYPE_ARGUMENT {
+			continue
+		}
+		for _, conclusionID := range conclusions[id] {
+			parent, ok := nodes[conclusionID]
+			if !ok {
+				fmt.Fprintf(logOutput, "----------------------------AIF conclusion %s of %s was skipped\n", conclusionID, id)
+				continue
+			}
+			parent.Children[node.ID] = Child{ID: node.ID, Polarity: node.Polarity}
+			parent.ChildrenOrder = append(parent.ChildrenOrder, node.ID)
+			node.Parents[parent.ID] = true
+		}
+		for _, premiseID := range premises[id] {
+			nodes[premiseID].Parents[node.ID] = true
+		}
+	}
+
+	for _, id := range order {
+		root.Nodes = append(root.Nodes, *nodes[id])
+	}
+	fmt.Fprintf(logOutput, "Read %d AIF nodes and %d edges into %d nodes\n", len(doc.Nodes), len(doc.Edges), len(root.Nodes))
+	return root, nil
+}
+
+const AIF_DEFAULT_INFERENCE = "Default Inference"
+const AIF_DEFAULT_CONFLICT = "Default Conflict"
+const AIF_TIME_LAYOUT = "2006-01-02 15:04:05"
+
+// exportAIF converts the graph into an AIF-JSON document:
+//   - Claims become I-nodes
+//   - PRO Arguments become RA-nodes and CON Arguments become CA-nodes, with an edge to their target
+//     (the I-node of the target Claim, or the RA/CA-node of the target Argument)
+//   - The base Claim of an Argument is its premise. When the base Claim is an MP Claim, its premises
+//     (in Premise.Order) are the premises of the RA/CA-node, which makes it a multi-premise node,
+//     and the MP Claim itself is not written as an I-node. The Arguments about the MP Claim point to
+//     that RA/CA-node instead.
+func exportAIF(g *Graph) AIFDocument {
+	doc := AIFDocument{Nodes: []AIFNode{}, Edges: []AIFEdge{}, Locutions: []json.RawMessage{}}
+	claims := g.ClaimsByArangoID()
+	args := g.ArgumentsByArangoID()
+	premises := g.PremisesByClaim()
+
+	baseClaims := map[string][]string{}
+	for _, bc := range g.BaseClaims {
+		baseClaims[bc.From] = append(baseClaims[bc.From], bc.To)
+	}
+
+	// MP Claims that are the base of an Argument are folded into their RA/CA-node
+	folded := map[string]bool{}
+	foldedInto := map[string]string{}
+	for _, arg := range g.Arguments {
+		for _, claimID := range baseClaims[arg.ArangoID()] {
+			if claim, ok := claims[claimID]; ok && claim.MultiPremise {
+				folded[claimID] = true
+				if _, ok := foldedInto[claimID]; !ok {
+					foldedInto[claimID] = arg.Key
+				}
+			}
+		}
+	}
+
+	for _, claim := range g.Claims {
+		if folded[claim.ArangoID()] {
+			continue
+		}
+		doc.Nodes = append(doc.Nodes, AIFNode{
+			ID:        AIFID(claim.Key),
+			Text:      claim.Title,
+			Type:      AIF_NODE_I,
+			Timestamp: aifTimestamp(claim.CreatedAt),
+		})
+	}
+
+	edgeCount := 0
+	addEdge := func(from, to string) {
+		edgeCount++
+		doc.Edges = append(doc.Edges, AIFEdge{
+			ID:     AIFID(fmt.Sprintf("%d", edgeCount)),
+			FromID: AIFID(from),
+			ToID:   AIFID(to),
+		})
+	}
+
+	for _, arg := range g.Arguments {
+		node := AIFNode{
+			ID:        AIFID(arg.Key),
+			Text:      arg.Title,
+			Type:      AIF_NODE_RA,
+			Timestamp: aifTimestamp(arg.CreatedAt),
+		}
+		if !arg.Pro {
+			node.Type = AIF_NODE_CA
+		}
+
+		premiseKeys := []string{}
+		for _, claimID := range baseClaims[arg.ArangoID()] {
+			claim, ok := claims[claimID]
+			if !ok {
+				fmt.Fprintf(logOutput, "----------------------------Base claim %s of argument %s not found\n", claimID, arg.Key)
+				continue
+			}
+			if folded[claimID] {
+				if node.Text == "" {
+					node.Text = claim.Title
+				}
+				for _, premise := range premises[claimID] {
+					if premiseClaim, ok := claims[premise.To]; ok {
+						premiseKeys = append(premiseKeys, premiseClaim.Key)
+					}
+				}
+			} else {
+				premiseKeys = append(premiseKeys, claim.Key)
+			}
+		}
+
+		if node.Text == "" {
+			if arg.Pro {
+				node.Text = AIF_DEFAULT_INFERENCE
+			} else {
+				node.Text = AIF_DEFAULT_CONFLICT
+			}
+		}
+		doc.Nodes = append(doc.Nodes, node)
+		for _, key := range premiseKeys {
+			addEdge(key, arg.Key)
+		}
+	}
+
+	// Inferences point from the target to the Argument, while in AIF the edge goes from the
+	// RA/CA-node to its conclusion
+	for _, inference := range g.Inferences {
+		arg, ok := args[inference.To]
+		if !ok {
+			fmt.Fprintf(logOutput, "----------------------------Inference %s points to unknown argument %s\n", inference.Key, inference.To)
+			continue
+		}
+		if key, ok := foldedInto[inference.From]; ok {
+			addEdge(arg.Key, key)
+		} else if claim, ok := claims[inference.From]; ok {
+			addEdge(arg.Key, claim.Key)
+		} else if target, ok := args[inference.From]; ok {
+			addEdge(arg.Key, target.Key)
+		} else {
+			fmt.Fprintf(logOutput, "----------------------------Inference %s comes from unknown target %s\n", inference.Key, inference.From)
+		}
+	}
+
+	return doc
+}
+
+func aifTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(AIF_TIME_LAYOUT)
+}
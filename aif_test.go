@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// An OVA map, with numeric IDs: 2 supports 1, 4 and 5 together support 1, 7 undercuts that support,
+// and a locution that is not part of the debate
+const testAIF = `{"nodes": [
+  {"nodeID": 1, "text": "We should reform pensions", "type": "I", "timestamp": "2016-01-27 12:07:20"},
+  {"nodeID": 2, "text": "The deficit is growing", "type": "I"},
+  {"nodeID": 3, "text": "Default Inference", "type": "RA"},
+  {"nodeID": 4, "text": "People live longer", "type": "I"},
+  {"nodeID": 5, "text": "Fewer workers per retiree", "type": "I"},
+  {"nodeID": 6, "text": "Default Inference", "type": "RA"},
+  {"nodeID": 7, "text": "Reform hurts the poor", "type": "I"},
+  {"nodeID": 8, "text": "Default Conflict", "type": "CA"},
+  {"nodeID": 9, "text": "Bob: we should reform pensions", "type": "L"}
+], "edges": [
+  {"edgeID": 1, "fromID": 2, "toID": 3}, {"edgeID": 2, "fromID": 3, "toID": 1},
+  {"edgeID": 3, "fromID": 4, "toID": 6}, {"edgeID": 4, "fromID": 5, "toID": 6}, {"edgeID": 5, "fromID": 6, "toID": 1},
+  {"edgeID": 6, "fromID": 7, "toID": 8}, {"edgeID": 7, "fromID": 8, "toID": 6}
+], "locutions": []}`
+
+func TestParseAIF(t *testing.T) {
+	root, err := parseAIF([]byte(testAIF))
+	if err != nil {
+		t.Fatalf("parseAIF: %s", err.Error())
+	}
+	nodes := map[string]DebateMapNode{}
+	for _, node := range root.Nodes {
+		nodes[node.ID] = node
+	}
+	if len(nodes) != 8 {
+		t.Fatalf("read %d nodes, expected the 5 I-nodes and 3 scheme nodes", len(nodes))
+	}
+	if _, ok := nodes["9"]; ok {
+		t.Errorf("the locution was read as a node")
+	}
+	if nodes["1"].Type != NODE_TYPE_CLAIM || nodes["1"].CreatedAt != 1453896440000 {
+		t.Errorf("unexpected claim 1: %+v", nodes["1"])
+	}
+
+	tests := []struct {
+		ID       string
+		Parent   string
+		Polarity int
+		Premises []string
+	}{
+		{"3", "1", ARGUMENT_POLARITY_PRO, []string{"2"}},
+		{"6", "1", ARGUMENT_POLARITY_PRO, []string{"4", "5"}},
+		{"8", "6", ARGUMENT_POLARITY_CON, []string{"7"}},
+	}
+	for _, test := range tests {
+		arg := nodes[test.ID]
+		if arg.Type != NODE_TYPE_ARGUMENT || arg.Polarity != test.Polarity || arg.MultiPremise != (len(test.Premises) > 1) {
+			t.Errorf("unexpected argument %s: %+v", test.ID, arg)
+		}
+		// As in Debate Map, the order also lists the arguments attacking or supporting the argument
+		premises := []string{}
+		for _, id := range arg.ChildrenOrder {
+			if child := NewChildFromData(id, arg.Children[id]); child != nil && child.Polarity == 0 {
+				premises = append(premises, id)
+			}
+		}
+		if !reflect.DeepEqual(premises, test.Premises) {
+			t.Errorf("argument %s has the premises %v, expected %v", test.ID, premises, test.Premises)
+		}
+		if child := NewChildFromData(test.ID, nodes[test.Parent].Children[test.ID]); child == nil || child.Polarity != test.Polarity {
+			t.Errorf("argument %s is not a child of %s with polarity %d", test.ID, test.Parent, test.Polarity)
+		}
+	}
+}
+
+func TestExportAIF(t *testing.T) {
+	root, err := parseAIF([]byte(testAIF))
+	if err != nil {
+		t.Fatalf("parseAIF: %s", err.Error())
+	}
+	g := buildGraph(root)
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+
+	// The multi-premise claim is folded back into its RA-node, so the export has the nodes of the map again
+	data, err := json.Marshal(exportAIF(g))
+	if err != nil {
+		t.Fatalf("writing the AIF: %s", err.Error())
+	}
+	doc := AIFDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("reading the AIF back: %s", err.Error())
+	}
+	types := map[string]int{}
+	for _, node := range doc.Nodes {
+		types[node.Type]++
+	}
+	if !reflect.DeepEqual(types, map[string]int{AIF_NODE_I: 5, AIF_NODE_RA: 2, AIF_NODE_CA: 1}) || len(doc.Edges) != 7 {
+		t.Errorf("exported the nodes %v and %d edges, expected 5 I-nodes, 2 RA-nodes, 1 CA-node and 7 edges", types, len(doc.Edges))
+	}
+
+	again, err := parseAIF(data)
+	if err != nil {
+		t.Fatalf("parseAIF of the export: %s", err.Error())
+	}
+	multiPremise := 0
+	for _, node := range again.Nodes {
+		if node.MultiPremise {
+			multiPremise++
+		}
+	}
+	if len(again.Nodes) != 8 || multiPremise != 1 {
+		t.Errorf("read the export into %d nodes, %d of them multi-premise, expected 8 and 1", len(again.Nodes), multiPremise)
+	}
+}
+
+func TestExportAIFArgumentAboutMPClaim(t *testing.T) {
+	root, err := parseAIF([]byte(testAIF))
+	if err != nil {
+		t.Fatalf("parseAIF: %s", err.Error())
+	}
+	g := buildGraph(root)
+
+	// An argument attacking the multi-premise claim of 6, as Debate Map data can have
+	var mpClaim Claim
+	for _, claim := range g.Claims {
+		if claim.MultiPremise {
+			mpClaim = claim
+		}
+	}
+	based := NewClaim(DebateMapNode{ID: "10", Current: Current{Title: TitleSet{Base: "The projections are wrong"}}})
+	attack := NewArgument(DebateMapNode{ID: "11"})
+	g.Claims = append(g.Claims, based)
+	g.Arguments = append(g.Arguments, attack)
+	g.Inferences = append(g.Inferences, NewInference(mpClaim.ArangoID(), attack))
+	g.BaseClaims = append(g.BaseClaims, NewBaseClaim(attack, based.ArangoID()))
+
+	// The attack points to the RA-node the claim is folded into, and every edge joins two nodes
+	doc := exportAIF(g)
+	nodes := map[AIFID]bool{}
+	for _, node := range doc.Nodes {
+		nodes[node.ID] = true
+	}
+	found := false
+	for _, edge := range doc.Edges {
+		if !nodes[edge.FromID] || !nodes[edge.ToID] {
+			t.Errorf("the edge %s joins %s and %s, which are not both nodes", edge.ID, edge.FromID, edge.ToID)
+		}
+		found = found || (edge.FromID == AIFID(attack.Key) && edge.ToID == AIFID(documentKey("arguments", "6")))
+	}
+	if !found {
+		t.Errorf("the attack on %s doesn't point to the RA-node 6", mpClaim.ID)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("writing the AIF: %s", err.Error())
+	}
+	again, err := parseAIF(data)
+	if err != nil {
+		t.Fatalf("parseAIF of the export: %s", err.Error())
+	}
+	g = buildGraph(again)
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+	if len(g.Arguments) != 4 || len(g.Inferences) != 4 {
+		t.Errorf("read the export into %d arguments and %d inferences, expected 4 and 4", len(g.Arguments), len(g.Inferences))
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Prefix of the pseudonyms that replace the Firebase UIDs
+const PSEUDONYM_PREFIX = "anon-"
+
+// The salt can be given in the environment instead of the command line, which other users can see
+const ANONYMIZE_SALT_ENV = "ANONYMIZE_SALT"
+
+// AnonymizeFlags choose how the graph is anonymized before it is written, for publishing it as open data
+type AnonymizeFlags struct {
+	Anonymize   bool
+	Salt        string
+	StripNotes  bool
+	JitterDates time.Duration
+}
+
+func (af *AnonymizeFlags) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&af.Anonymize, "anonymize", false, "replace the Firebase UIDs of the users with pseudonyms, and drop their names and accounts")
+	fs.StringVar(&af.Salt, "anonymize-salt", os.Getenv(ANONYMIZE_SALT_ENV), "secret the pseudonyms are derived from; the same salt gives the same pseudonyms (default $"+ANONYMIZE_SALT_ENV+")")
+	fs.BoolVar(&af.StripNotes, "strip-notes", false, "with --anonymize, also remove the notes of the claims and arguments")
+	fs.DurationVar(&af.JitterDates, "jitter-dates", 0, "with --anonymize, also move every date by up to this much, earlier or later (e.g. 24h)")
+}
+
+// Check tells whether the flags make sense together, before anything is read
+func (af AnonymizeFlags) Check() error {
+	if !af.Anonymize {
+		if af.StripNotes || af.JitterDates != 0 {
+			return fmt.Errorf("--strip-notes and --jitter-dates need --anonymize")
+		}
+		return nil
+	}
+	if af.Salt == "" {
+		return fmt.Errorf("--anonymize needs a salt, with --anonymize-salt or $%s", ANONYMIZE_SALT_ENV)
+	}
+	if af.JitterDates < 0 {
+		return fmt.Errorf("--jitter-dates must not be negative, found %s", af.JitterDates)
+	}
+	return nil
+}
+
+// Apply anonymizes the graph if asked to
+func (af AnonymizeFlags) Apply(g *Graph) {
+	if af.Anonymize {
+		anonymizeGraph(g, af)
+	}
+}
+
+func (af AnonymizeFlags) hash(kind, value string) []byte {
+	mac := hmac.New(sha256.New, []byte(af.Salt))
+	mac.Write([]byte(kind + "/" + value))
+	return mac.Sum(nil)
+}
+
+// pseudonym replaces a UID with a keyed hash of it: the same UID always gets the same pseudonym
+// with the same salt, but the UID can't be found from the pseudonym without the salt
+func (af AnonymizeFlags) pseudonym(uid string) string {
+	if uid == "" {
+		return ""
+	}
+	return PSEUDONYM_PREFIX + hex.EncodeToString(af.hash("user", uid)[:12])
+}
+
+// jitter moves a date by up to JitterDates, by an offset derived from the date itself,
+// so that a document and its edges, which share their date, are moved together
+func (af AnonymizeFlags) jitter(t time.Time) time.Time {
+	if af.JitterDates == 0 || t.IsZero() {
+		return t
+	}
+	span := uint64(af.JitterDates/time.Millisecond)*2 + 1
+	offset := int64(binary.BigEndian.Uint64(af.hash("date", strconv.FormatInt(t.UnixNano(), 10)))%span) - int64(af.JitterDates/time.Millisecond)
+	return t.Add(time.Duration(offset) * time.Millisecond)
+}
+
+// anonymizeGraph replaces the creator of every document and edge with its pseudonym, and the users' own IDs.
+// The keys derived from a UID (those of the users and their ratings) are derived from the pseudonym instead,
+// and the edges are repointed to them, so nothing in the graph leads back to the UIDs.
+func anonymizeGraph(g *Graph, af AnonymizeFlags) {
+	handles := map[string]string{}
+	rekey := func(handle string) string {
+		if newHandle, ok := handles[handle]; ok {
+			return newHandle
+		}
+		return handle
+	}
+
+	for i, user := range g.Users {
+		g.Users[i].ID = af.pseudonym(user.ID)
+		g.Users[i].Key = documentKey("users", g.Users[i].ID)
+		g.Users[i].Name = ""
+		g.Users[i].Account = ""
+		g.Users[i].CreatedAt = af.jitter(user.CreatedAt)
+		handles[user.ArangoID()] = g.Users[i].ArangoID()
+	}
+	for i, rating := range g.Ratings {
+		g.Ratings[i].Creator = af.pseudonym(rating.Creator)
+		g.Ratings[i].Key = ratingKey(rating.Node, rating.Type, g.Ratings[i].Creator)
+		g.Ratings[i].CreatedAt = af.jitter(rating.CreatedAt)
+		handles[rating.ArangoID()] = g.Ratings[i].ArangoID()
+	}
+
+	notes := 0
+	for i, claim := range g.Claims {
+		g.Claims[i].Creator = af.pseudonym(claim.Creator)
+		g.Claims[i].CreatedAt = af.jitter(claim.CreatedAt)
+		if af.StripNotes && claim.Note != "" {
+			g.Claims[i].Note = ""
+			notes++
+		}
+	}
+	for i, arg := range g.Arguments {
+		g.Arguments[i].Creator = af.pseudonym(arg.Creator)
+		g.Arguments[i].CreatedAt = af.jitter(arg.CreatedAt)
+		if af.StripNotes && arg.Note != "" {
+			g.Arguments[i].Note = ""
+			notes++
+		}
+	}
+
+	for i, inference := range g.Inferences {
+		g.Inferences[i].Creator = af.pseudonym(inference.Creator)
+		g.Inferences[i].CreatedAt = af.jitter(inference.CreatedAt)
+	}
+	for i, bc := range g.BaseClaims {
+		g.BaseClaims[i].Creator = af.pseudonym(bc.Creator)
+		g.BaseClaims[i].CreatedAt = af.jitter(bc.CreatedAt)
+	}
+	for i, premise := range g.Premises {
+		g.Premises[i].Creator = af.pseudonym(premise.Creator)
+		g.Premises[i].CreatedAt = af.jitter(premise.CreatedAt)
+	}
+	for i, edge := range g.RatingTargets {
+		g.RatingTargets[i].Creator = af.pseudonym(edge.Creator)
+		g.RatingTargets[i].CreatedAt = af.jitter(edge.CreatedAt)
+		g.RatingTargets[i].From = rekey(edge.From)
+		g.RatingTargets[i].Key = edgeKey("rating_targets", g.RatingTargets[i].From, edge.To)
+	}
+	for i, creation := range g.Creations {
+		g.Creations[i].Creator = af.pseudonym(creation.Creator)
+		g.Creations[i].CreatedAt = af.jitter(creation.CreatedAt)
+		g.Creations[i].From = rekey(creation.From)
+		g.Creations[i].To = rekey(creation.To)
+		g.Creations[i].Key = edgeKey("created", g.Creations[i].From, g.Creations[i].To)
+	}
+
+	fmt.Fprintf(logOutput, "Anonymized %d users", len(g.Users))
+	if af.StripNotes {
+		fmt.Fprintf(logOutput, ", removed %d notes", notes)
+	}
+	if af.JitterDates != 0 {
+		fmt.Fprintf(logOutput, ", moved the dates by up to %s", af.JitterDates)
+	}
+	fmt.Fprintln(logOutput)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnonymizeFlagsCheck(t *testing.T) {
+	tests := []struct {
+		Flags AnonymizeFlags
+		Error string
+	}{
+		{AnonymizeFlags{}, ""},
+		{AnonymizeFlags{Anonymize: true, Salt: "s", StripNotes: true, JitterDates: time.Hour}, ""},
+		{AnonymizeFlags{StripNotes: true}, "need --anonymize"},
+		{AnonymizeFlags{JitterDates: time.Hour}, "need --anonymize"},
+		{AnonymizeFlags{Anonymize: true}, "needs a salt"},
+		{AnonymizeFlags{Anonymize: true, Salt: "s", JitterDates: -time.Hour}, "must not be negative"},
+	}
+	for _, test := range tests {
+		err := test.Flags.Check()
+		if (test.Error == "" && err != nil) || (test.Error != "" && (err == nil || !strings.Contains(err.Error(), test.Error))) {
+			t.Errorf("Check(%+v) returned the error %v, expected %q", test.Flags, err, test.Error)
+		}
+	}
+}
+
+func TestAnonymizeGraph(t *testing.T) {
+	g := testGraph(t)
+	g.Claims[0].Note = "Written by Ana for the workshop"
+	dates := map[string]time.Time{}
+	for _, claim := range g.Claims {
+		dates[claim.ID] = claim.CreatedAt
+	}
+	af := AnonymizeFlags{Anonymize: true, Salt: "secret", StripNotes: true, JitterDates: 24 * time.Hour}
+	af.Apply(g)
+
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+	if err := checkUniqueIndexes(g); err != nil {
+		t.Fatalf("checkUniqueIndexes: %s", err.Error())
+	}
+	data, err := json.Marshal(g.Documents())
+	if err != nil {
+		t.Fatalf("writing the graph: %s", err.Error())
+	}
+	for _, personal := range []string{`"u1"`, `"u2"`, `"u3"`, "Ana", "Bruno"} {
+		if strings.Contains(string(data), personal) {
+			t.Errorf("the anonymized graph still contains %s", personal)
+		}
+	}
+
+	// The same UID gets the same pseudonym everywhere, which the edges lead to
+	users := map[string]bool{}
+	for _, user := range g.Users {
+		if !strings.HasPrefix(user.ID, PSEUDONYM_PREFIX) || user.Key != documentKey("users", user.ID) {
+			t.Errorf("unexpected user: %+v", user)
+		}
+		users[user.ArangoID()] = true
+	}
+	for _, creation := range g.Creations {
+		if !users[creation.From] {
+			t.Errorf("the created edge %s comes from an unknown user %s", creation.Key, creation.From)
+		}
+	}
+	if claim := g.Claims[0]; claim.Creator != af.pseudonym("u1") || claim.Note != "" {
+		t.Errorf("the claim %s has the creator %s and the note %q, expected %s and none", claim.ID, claim.Creator, claim.Note, af.pseudonym("u1"))
+	}
+
+	for _, claim := range g.Claims {
+		if moved := claim.CreatedAt.Sub(dates[claim.ID]); moved > af.JitterDates || moved < -af.JitterDates {
+			t.Errorf("the date of %s moved by %s, more than %s", claim.ID, moved, af.JitterDates)
+		}
+	}
+}
+
+func TestPseudonym(t *testing.T) {
+	af := AnonymizeFlags{Anonymize: true, Salt: "secret"}
+	other := AnonymizeFlags{Anonymize: true, Salt: "other"}
+	if af.pseudonym("u1") != af.pseudonym("u1") || af.pseudonym("u1") == af.pseudonym("u2") {
+		t.Errorf("the pseudonyms of u1 and u2 are %s and %s", af.pseudonym("u1"), af.pseudonym("u2"))
+	}
+	if af.pseudonym("u1") == other.pseudonym("u1") {
+		t.Errorf("two salts give u1 the same pseudonym %s", af.pseudonym("u1"))
+	}
+	if af.pseudonym("") != "" {
+		t.Errorf("a missing creator got the pseudonym %s", af.pseudonym(""))
+	}
+}
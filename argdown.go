@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Argdown (https://argdown.org) element kinds
+const ARGDOWN_STATEMENT = '['
+const ARGDOWN_ARGUMENT = '<'
+
+var argdownRelationMarker = regexp.MustCompile(`^(<\+|<-|<_|\+>|->|_>|><|\+|-|_)\s+(.*)$`)
+var argdownElement = regexp.MustCompile(`^(\[([^\]]+)\]|<([^>]+)>)(:\s*(.*))?$`)
+var argdownPCSStatement = regexp.MustCompile(`^\((\d+)\)\s*(.*)$`)
+var argdownInference = regexp.MustCompile(`^--.*$`)
+var argdownTag = regexp.MustCompile(`(^|\s)#(\([^)]*\)|[\w-]+)`)
+var argdownLineComment = regexp.MustCompile(`(^|\s)//.*$`)
+var argdownBlockComments = map[string]string{"/*": "*/", "<!--": "-->"}
+
+// An argdownRef identifies a statement ([Title]) or an argument (<Title>)
+type argdownRef struct {
+	Kind  rune
+	Title string
+}
+
+func (ref argdownRef) ID() string {
+	if ref.Kind == ARGDOWN_ARGUMENT {
+		return "<" + ref.Title + ">"
+	}
+	return "[" + ref.Title + "]"
+}
+
+// An argdownRelation is a support (PRO) or attack (CON) from one element to another
+type argdownRelation struct {
+	From     argdownRef
+	To       argdownRef
+	Polarity int
+}
+
+type argdownParser struct {
+	nodes     map[string]*DebateMapNode
+	order     []string
+	relations []argdownRelation
+	seen      map[[2]argdownRef]bool
+}
+
+// parseArgdown converts an Argdown document into Debate Map nodes, so the rest of the import
+// can treat it like any other export:
+//   - Statements ([Title]: text) become Claims
+//   - Arguments (<Title>: text) become Arguments. Their premise-conclusion structure, if any, gives their
+//     base Claim: the premise itself when there is only one, or else an MP Claim (see ConvertToMPClaim)
+//     with the premises in their numbered order. The argument supports its conclusion.
+//   - Support (+, <+, +>) and attack (-, <-, ->, _) relations become PRO and CON Arguments.
+//     A statement supporting or attacking another element gets its own Argument, based on the statement,
+//     so a statement supporting several elements gets an Argument for each of them.
+//
+// Headings, tags, comments and front matter are ignored.
+func parseArgdown(data []byte) (DebateMapRoot, error) {
+	p := argdownParser{
+		nodes: map[string]*DebateMapNode{},
+		seen:  map[[2]argdownRef]bool{},
+	}
+
+	type stackEntry struct {
+		indent int
+		ref    argdownRef
+	}
+	stack := []stackEntry{}
+
+	var pcsArgument *argdownRef
+	var lastArgument *argdownRef
+	var pcsPremises, pcsConclusions []argdownRef
+	afterInference := false
+	finishPCS := func() {
+		if pcsArgument != nil {
+			p.addPCS(*pcsArgument, pcsPremises, pcsConclusions)
+		}
+		pcsArgument = nil
+		pcsPremises = nil
+		pcsConclusions = nil
+		afterInference = false
+	}
+
+	lines := stripArgdownComments(data)
+	for lineNumber, raw := range lines {
+		line := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			stack = stack[:0]
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "#(") {
+			// Heading
+			stack = stack[:0]
+			finishPCS()
+			continue
+		}
+		trimmed = strings.TrimSpace(argdownTag.ReplaceAllString(trimmed, "$1"))
+
+		// Premise-conclusion structure
+		if match := argdownPCSStatement.FindStringSubmatch(trimmed); match != nil {
+			if pcsArgument == nil {
+				if lastArgument == nil {
+					return DebateMapRoot{}, fmt.Errorf("line %d: premise-conclusion structure without an argument", lineNumber+1)
+				}
+				pcsArgument = lastArgument
+			}
+			ref := p.addElement(match[2])
+			if afterInference {
+				pcsConclusions = append(pcsConclusions, ref)
+			} else {
+				pcsPremises = append(pcsPremises, ref)
+			}
+			afterInference = false
+			continue
+		}
+		if argdownInference.MatchString(trimmed) {
+			afterInference = pcsArgument != nil
+			continue
+		}
+		finishPCS()
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if match := argdownRelationMarker.FindStringSubmatch(trimmed); match != nil {
+			// Find the parent: the closest element above with less indentation
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				return DebateMapRoot{}, fmt.Errorf("line %d: relation without a parent element: %s", lineNumber+1, trimmed)
+			}
+			parent := stack[len(stack)-1].ref
+			ref := p.addElement(match[2])
+			switch match[1] {
+			case "+", "<+":
+				p.addRelation(ref, parent, ARGUMENT_POLARITY_PRO)
+			case "-", "<-", "_", "<_", "><":
+				p.addRelation(ref, parent, ARGUMENT_POLARITY_CON)
+			case "+>":
+				p.addRelation(parent, ref, ARGUMENT_POLARITY_PRO)
+			case "->", "_>":
+				p.addRelation(parent, ref, ARGUMENT_POLARITY_CON)
+			}
+			stack = append(stack, stackEntry{indent: indent, ref: ref})
+		} else {
+			ref := p.addElement(trimmed)
+			stack = append(stack[:0], stackEntry{indent: indent, ref: ref})
+			if ref.Kind == ARGDOWN_ARGUMENT {
+				lastArgument = &ref
+			}
+		}
+	}
+	finishPCS()
+
+	p.resolveRelations()
+
+	root := DebateMapRoot{}
+	for _, id := range p.order {
+		root.Nodes = append(root.Nodes, *p.nodes[id])
+	}
+	fmt.Fprintf(logOutput, "Read %d Argdown elements and %d relations\n", len(root.Nodes), len(p.relations))
+	return root, nil
+}
+
+// addElement parses a statement or argument definition or reference, and creates its node if it's new.
+// Text without a [Title] or <Title> is an anonymous statement, identified by its text.
+func (p *argdownParser) addElement(text string) argdownRef {
+	ref := argdownRef{Kind: ARGDOWN_STATEMENT, Title: text}
+	description := text
+	if match := argdownElement.FindStringSubmatch(text); match != nil {
+		if match[2] != "" {
+			ref = argdownRef{Kind: ARGDOWN_STATEMENT, Title: strings.TrimSpace(match[2])}
+		} else {
+			ref = argdownRef{Kind: ARGDOWN_ARGUMENT, Title: strings.TrimSpace(match[3])}
+		}
+		description = strings.TrimSpace(match[5])
+	}
+
+	id := ref.ID()
+	node, ok := p.nodes[id]
+	if !ok {
+		node = &DebateMapNode{
+			ID:       id,
+			Current:  Current{ID: id, Title: TitleSet{Base: ref.Title}},
+			Parents:  map[string]interface{}{},
+			Children: map[string]interface{}{},
+		}
+		if ref.Kind == ARGDOWN_ARGUMENT {
+			node.Type = NODE_TYPE_ARGUMENT
+			node.Polarity = ARGUMENT_POLARITY_PRO
+			node.Current.ArgumentType = ARGUMENT_TYPE_ALL
+		} else {
+			node.Type = NODE_TYPE_CLAIM
+		}
+		p.nodes[id] = node
+		p.order = append(p.order, id)
+	}
+	if description != "" && (node.Current.Title.Base == ref.Title || node.Current.Title.Base == "") {
+		node.Current.Title.Base = description
+	}
+	return ref
+}
+
+// addRelation records that one element supports or attacks another.
+// Only the first relation between two elements counts.
+func (p *argdownParser) addRelation(from, to argdownRef, polarity int) {
+	pair := [2]argdownRef{from, to}
+	if p.seen[pair] {
+		return
+	}
+	p.seen[pair] = true
+	p.relations = append(p.relations, argdownRelation{From: from, To: to, Polarity: polarity})
+}
+
+// addPCS makes the premises the base of the argument, and the argument a support of its conclusion
+func (p *argdownParser) addPCS(arg argdownRef, premises, conclusions []argdownRef) {
+	node := p.nodes[arg.ID()]
+	for _, premise := range premises {
+		id := premise.ID()
+		if _, ok := node.Children[id]; ok {
+			continue
+		}
+		node.Children[id] = Child{ID: id}
+		node.ChildrenOrder = append(node.ChildrenOrder, id)
+		p.nodes[id].Parents[node.ID] = true
+	}
+	node.MultiPremise = len(node.ChildrenOrder) > 1
+	if len(conclusions) > 0 {
+		p.addRelation(arg, conclusions[len(conclusions)-1], ARGUMENT_POLARITY_PRO)
+	}
+}
+
+// resolveRelations adds each supporting or attacking element as a child of its target
+func (p *argdownParser) resolveRelations() {
+	for _, relation := range p.relations {
+		from := p.nodes[relation.From.ID()]
+		to := p.nodes[relation.To.ID()]
+		if from.Type == NODE_TYPE_CLAIM {
+			// A statement only supports or attacks through an argument based on it: one for each relation,
+			// identified by the relation, as the statement can take part in several
+			id := fmt.Sprintf("%s %s %s", relation.From.ID(), argdownRelationSymbol(relation.Polarity), relation.To.ID())
+			argNode := &DebateMapNode{
+				ID:       id,
+				Type:     NODE_TYPE_ARGUMENT,
+				Polarity: relation.Polarity,
+				Current:  Current{ID: id},
+				Parents:  map[string]interface{}{},
+				Children: map[string]interface{}{from.ID: Child{ID: from.ID}},
+			}
+			from.Parents[id] = true
+			p.nodes[id] = argNode
+			p.order = append(p.order, id)
+			from = argNode
+		}
+		if from.Type == NODE_TYPE_ARGUMENT {
+			from.Polarity = relation.Polarity
+		}
+		to.Children[from.ID] = Child{ID: from.ID, Polarity: relation.Polarity}
+		to.ChildrenOrder = append(to.ChildrenOrder, from.ID)
+		from.Parents[to.ID] = true
+	}
+}
+
+func argdownRelationSymbol(polarity int) string {
+	if polarity == ARGUMENT_POLARITY_CON {
+		return "-"
+	}
+	return "+"
+}
+
+// stripArgdownComments removes front matter, /* */ and <!-- --> block comments and // line comments,
+// keeping the line structure
+func stripArgdownComments(data []byte) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(trimInput(data)))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	inBlock := ""
+	inFrontMatter := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(lines) == 0 && strings.TrimSpace(line) == "===" {
+			inFrontMatter = true
+			lines = append(lines, "")
+			continue
+		}
+		if inFrontMatter {
+			if strings.TrimSpace(line) == "===" {
+				inFrontMatter = false
+			}
+			lines = append(lines, "")
+			continue
+		}
+
+		out := ""
+		for line != "" {
+			if inBlock != "" {
+				end := strings.Index(line, inBlock)
+				if end < 0 {
+					line = ""
+					break
+				}
+				line = line[end+len(inBlock):]
+				inBlock = ""
+				continue
+			}
+			start, open := -1, ""
+			for _, o := range []string{"/*", "<!--"} {
+				if i := strings.Index(line, o); i >= 0 && (start < 0 || i < start) {
+					start, open = i, o
+				}
+			}
+			if start < 0 {
+				out += line
+				break
+			}
+			out += line[:start]
+			line = line[start+len(open):]
+			inBlock = argdownBlockComments[open]
+		}
+		lines = append(lines, argdownLineComment.ReplaceAllString(out, ""))
+	}
+	return lines
+}
+
+// looksLikeArgdown is used to recognize Argdown documents that don't have an .argdown extension,
+// e.g. when reading from the standard input
+func looksLikeArgdown(data []byte) bool {
+	for _, line := range stripArgdownComments(data) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return argdownElement.MatchString(line)
+	}
+	return false
+}
+
+// argdownWriter renders a Graph as nested Argdown, starting from each root Claim
+type argdownWriter struct {
+	w            *bufio.Writer
+	g            *Graph
+	claims       map[string]Claim
+	args         map[string]Argument
+	argsByTarget map[string][]Argument
+	baseClaims   map[string][]string
+	premises     map[string][]Premise
+	defined      map[string]bool
+	expanded     map[string]bool
+	pcs          []Argument
+}
+
+// writeArgdown walks the graph from each root Claim (one that is neither the base of an Argument nor a premise),
+// writing its pro (+) and con (-) Arguments as nested Argdown. An Argument with no title and no Arguments of its own
+// is written as its base statement; others are written as <arguments>, with their premises (the base Claim, or the
+// premises of an MP Claim in Premise.Order) in a premise-conclusion structure at the end.
+func writeArgdown(out io.Writer, g *Graph) error {
+	aw := argdownWriter{
+		w:            bufio.NewWriter(out),
+		g:            g,
+		claims:       g.ClaimsByArangoID(),
+		args:         g.ArgumentsByArangoID(),
+		argsByTarget: map[string][]Argument{},
+		baseClaims:   map[string][]string{},
+		premises:     g.PremisesByClaim(),
+		defined:      map[string]bool{},
+		expanded:     map[string]bool{},
+	}
+	for _, inference := range g.Inferences {
+		if arg, ok := aw.args[inference.To]; ok {
+			aw.argsByTarget[inference.From] = append(aw.argsByTarget[inference.From], arg)
+		}
+	}
+	for _, args := range aw.argsByTarget {
+		sortArguments(args)
+	}
+	notRoot := map[string]bool{}
+	for _, bc := range g.BaseClaims {
+		aw.baseClaims[bc.From] = append(aw.baseClaims[bc.From], bc.To)
+		notRoot[bc.To] = true
+	}
+	for _, premise := range g.Premises {
+		notRoot[premise.To] = true
+	}
+
+	claims := append([]Claim{}, g.Claims...)
+	sortClaims(claims)
+	for _, claim := range claims {
+		if !notRoot[claim.ArangoID()] && !claim.MultiPremise {
+			aw.writeClaimTree(claim)
+		}
+	}
+	// Claims that are only used as premises can still have Arguments of their own
+	for _, claim := range claims {
+		if !aw.expanded[claim.ArangoID()] && len(aw.argsByTarget[claim.ArangoID()]) > 0 {
+			aw.writeClaimTree(claim)
+		}
+	}
+	for i := 0; i < len(aw.pcs); i++ {
+		aw.writePCS(aw.pcs[i])
+	}
+	return aw.w.Flush()
+}
+
+func (aw *argdownWriter) writeClaimTree(claim Claim) {
+	aw.writeLine(0, "", aw.statement(claim))
+	aw.writeArguments(1, claim.ArangoID())
+	aw.w.WriteString("\n")
+}
+
+// writeArguments writes the Arguments targeting a Claim or Argument, indented under it
+func (aw *argdownWriter) writeArguments(depth int, targetID string) {
+	if aw.expanded[targetID] {
+		return
+	}
+	aw.expanded[targetID] = true
+	for _, arg := range aw.argsByTarget[targetID] {
+		relation := "+ "
+		if !arg.Pro {
+			relation = "- "
+		}
+		bases := aw.baseClaims[arg.ArangoID()]
+		if arg.Title == "" && len(bases) == 1 && len(aw.argsByTarget[arg.ArangoID()]) == 0 {
+			if base, ok := aw.claims[bases[0]]; ok && !base.MultiPremise {
+				aw.writeLine(depth, relation, aw.statement(base))
+				aw.writeArguments(depth+1, base.ArangoID())
+				continue
+			}
+		}
+		aw.writeLine(depth, relation, aw.argument(arg))
+		if len(bases) > 0 {
+			aw.pcs = append(aw.pcs, arg)
+		}
+		aw.writeArguments(depth+1, arg.ArangoID())
+	}
+}
+
+func (aw *argdownWriter) writePCS(arg Argument) {
+	aw.writeLine(0, "", "<"+argdownTitle(arg.ID, arg.Key)+">")
+	aw.w.WriteString("\n")
+	n := 0
+	for _, baseID := range aw.baseClaims[arg.ArangoID()] {
+		base, ok := aw.claims[baseID]
+		if !ok {
+			continue
+		}
+		if base.MultiPremise {
+			for _, premise := range aw.premises[baseID] {
+				if claim, ok := aw.claims[premise.To]; ok {
+					n++
+					aw.writeLine(0, fmt.Sprintf("(%d) ", n), aw.statement(claim))
+				}
+			}
+		} else {
+			n++
+			aw.writeLine(0, fmt.Sprintf("(%d) ", n), aw.statement(base))
+		}
+	}
+	// Argdown conclusions are supported by their argument, so only PRO Arguments get one,
+	// and the attacks are left to the relations in the tree
+	if arg.Pro {
+		for _, inference := range aw.g.Inferences {
+			if target, ok := aw.claims[inference.From]; ok && inference.To == arg.ArangoID() {
+				aw.writeLine(0, "", "----")
+				n++
+				aw.writeLine(0, fmt.Sprintf("(%d) ", n), aw.statement(target))
+				break
+			}
+		}
+	}
+	aw.w.WriteString("\n")
+}
+
+// statement defines a Claim the first time it is written, and refers to it afterwards
+func (aw *argdownWriter) statement(claim Claim) string {
+	title := "[" + argdownTitle(claim.ID, claim.Key) + "]"
+	if aw.defined[claim.ArangoID()] || claim.Title == "" {
+		return title
+	}
+	aw.defined[claim.ArangoID()] = true
+	return title + ": " + argdownText(claim.Title)
+}
+
+// argument defines an Argument, using the title of its base Claim if it has none of its own
+func (aw *argdownWriter) argument(arg Argument) string {
+	title := "<" + argdownTitle(arg.ID, arg.Key) + ">"
+	text := arg.Title
+	if text == "" {
+		for _, baseID := range aw.baseClaims[arg.ArangoID()] {
+			if base, ok := aw.claims[baseID]; ok && base.Title != "" {
+				text = base.Title
+				break
+			}
+		}
+	}
+	if text == "" {
+		return title
+	}
+	return title + ": " + argdownText(text)
+}
+
+func (aw *argdownWriter) writeLine(depth int, prefix, text string) {
+	aw.w.WriteString(strings.Repeat("  ", depth) + prefix + text + "\n")
+}
+
+// argdownTitle uses the Debate Map ID of an element as its Argdown title, as it stays the same
+// across imports (unlike the document key)
+func argdownTitle(id, key string) string {
+	title := id
+	if title == "" {
+		title = key
+	}
+	return strings.NewReplacer("[", "(", "]", ")", "<", "(", ">", ")").Replace(title)
+}
+
+// argdownText keeps the text of an element on a single line
+func argdownText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func sortClaims(claims []Claim) {
+	sort.SliceStable(claims, func(i, j int) bool {
+		if !claims[i].CreatedAt.Equal(claims[j].CreatedAt) {
+			return claims[i].CreatedAt.Before(claims[j].CreatedAt)
+		}
+		return claims[i].Key < claims[j].Key
+	})
+}
+
+// sortArguments puts the PRO Arguments first, and then orders them by creation
+func sortArguments(args []Argument) {
+	sort.SliceStable(args, func(i, j int) bool {
+		if args[i].Pro != args[j].Pro {
+			return args[i].Pro
+		}
+		if !args[i].CreatedAt.Equal(args[j].CreatedAt) {
+			return args[i].CreatedAt.Before(args[j].CreatedAt)
+		}
+		return args[i].Key < args[j].Key
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func argdownNodes(t *testing.T, doc string) map[string]DebateMapNode {
+	t.Helper()
+	root, err := parseArgdown([]byte(doc))
+	if err != nil {
+		t.Fatalf("parseArgdown: %s", err.Error())
+	}
+	nodes := map[string]DebateMapNode{}
+	for _, node := range root.Nodes {
+		nodes[node.ID] = node
+	}
+	return nodes
+}
+
+// childOf returns the child of the node with the given polarity, checking there is exactly one
+func childOf(t *testing.T, node DebateMapNode, polarity int) string {
+	t.Helper()
+	found := []string{}
+	for id, data := range node.Children {
+		if child := NewChildFromData(id, data); child != nil && child.Polarity == polarity {
+			found = append(found, child.ID)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("node %s has children %v with polarity %d, expected one", node.ID, found, polarity)
+	}
+	return found[0]
+}
+
+func TestArgdownNestedRelations(t *testing.T) {
+	nodes := argdownNodes(t, `
+[Reform]: Brazil should reform its pension system.
+  + <Deficit>: The deficit keeps growing.
+    - [Numbers]: The numbers are disputed.
+`)
+	reform := nodes["[Reform]"]
+	if reform.Type != NODE_TYPE_CLAIM || reform.Current.Title.Base != "Brazil should reform its pension system." {
+		t.Fatalf("unexpected [Reform] node: %+v", reform)
+	}
+	if id := childOf(t, reform, ARGUMENT_POLARITY_PRO); id != "<Deficit>" {
+		t.Errorf("[Reform] is supported by %s, expected <Deficit>", id)
+	}
+
+	// The statement attacking the argument does so through an argument based on it
+	attack := nodes[childOf(t, nodes["<Deficit>"], ARGUMENT_POLARITY_CON)]
+	if attack.Type != NODE_TYPE_ARGUMENT || attack.Polarity != ARGUMENT_POLARITY_CON {
+		t.Fatalf("unexpected attack on <Deficit>: %+v", attack)
+	}
+	if _, ok := attack.Children["[Numbers]"]; !ok || len(attack.Children) != 1 {
+		t.Errorf("the attack on <Deficit> has children %v, expected [Numbers]", attack.Children)
+	}
+}
+
+func TestArgdownSharedStatement(t *testing.T) {
+	root, err := parseArgdown([]byte(`
+[B]: Nuclear is clean.
+  + [A]: Nuclear emits little CO2.
+
+[C]: Nuclear is dangerous.
+  - [A]
+`))
+	if err != nil {
+		t.Fatalf("parseArgdown: %s", err.Error())
+	}
+	nodes := map[string]DebateMapNode{}
+	for _, node := range root.Nodes {
+		nodes[node.ID] = node
+	}
+	pro := childOf(t, nodes["[B]"], ARGUMENT_POLARITY_PRO)
+	con := childOf(t, nodes["[C]"], ARGUMENT_POLARITY_CON)
+	if pro == con {
+		t.Fatalf("[B] and [C] share the argument %s", pro)
+	}
+	for _, id := range []string{pro, con} {
+		if _, ok := nodes[id].Children["[A]"]; nodes[id].Type != NODE_TYPE_ARGUMENT || !ok {
+			t.Errorf("%s should be an argument based on [A]: %+v", id, nodes[id])
+		}
+	}
+
+	g := buildGraph(root)
+	if err := checkUniqueIndexes(g); err != nil {
+		t.Fatalf("checkUniqueIndexes: %s", err.Error())
+	}
+	if len(g.Claims) != 3 || len(g.Arguments) != 2 || len(g.BaseClaims) != 2 {
+		t.Fatalf("built %d claims, %d arguments and %d base claims, expected 3, 2 and 2", len(g.Claims), len(g.Arguments), len(g.BaseClaims))
+	}
+	for _, arg := range g.Arguments {
+		if arg.ClaimID != "[A]" {
+			t.Errorf("argument %s is based on %q, expected [A]", arg.ID, arg.ClaimID)
+		}
+	}
+}
+
+func TestArgdownPremiseConclusion(t *testing.T) {
+	nodes := argdownNodes(t, `
+[Reform]: Brazil should reform its pension system.
+  - <Military>
+
+<Military>: Brazil should wait for a government that is not protective of the military.
+
+(1) [Protective]: The current government protects the military.
+(2) Reforms under such a government are unfair.
+----
+(3) [Reform]
+`)
+	military := nodes["<Military>"]
+	if !military.MultiPremise {
+		t.Errorf("<Military> has two premises but is not multi-premise")
+	}
+	premises := []string{"[Protective]", "[Reforms under such a government are unfair.]"}
+	if !reflect.DeepEqual(military.ChildrenOrder, premises) {
+		t.Errorf("<Military> has the premises %v, expected %v", military.ChildrenOrder, premises)
+	}
+	// The argument attacks [Reform], and the conclusion doesn't turn it into a support
+	if id := childOf(t, nodes["[Reform]"], ARGUMENT_POLARITY_CON); id != "<Military>" {
+		t.Errorf("[Reform] is attacked by %s, expected <Military>", id)
+	}
+
+	g := buildGraph(DebateMapRoot{Nodes: []DebateMapNode{nodes["[Reform]"], military, nodes["[Protective]"], nodes[premises[1]]}})
+	orders := map[string]int{}
+	for _, premise := range g.Premises {
+		orders[premise.To] = premise.Order
+	}
+	claims := map[string]Claim{}
+	for _, claim := range g.Claims {
+		claims[claim.ID] = claim
+	}
+	for i, id := range premises {
+		if order := orders[claims[id].ArangoID()]; order != i+1 {
+			t.Errorf("premise %s has the order %d, expected %d", id, order, i+1)
+		}
+	}
+	if mp := claims["<Military>"+MP_CLAIM_ID_SUFFIX]; !mp.MultiPremise || mp.PremiseRule != PREMISE_RULE_ALL {
+		t.Errorf("unexpected multi-premise claim: %+v", mp)
+	}
+}
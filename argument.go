@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"time"
-
-	"github.com/google/uuid"
 )
 
+// The relevance and strength of an argument without ratings, before it is scored
+const ARGUMENT_DEFAULT_RELEVANCE = 1.00
+const ARGUMENT_DEFAULT_STRENGTH = 0.50
+
 type Argument struct {
 	Key              string    `json:"_key"`
 	ID               string    `json:"id"`
@@ -22,6 +24,8 @@ type Argument struct {
 	Pro              bool      `json:"pro"`
 	Relevance        float32   `json:"relevance"`
 	Str              float32   `json:"strength"`
+	Terms            []string  `json:"terms,omitempty"`
+	Language         string    `json:"lang"`
 }
 
 func (arg Argument) ArangoID() string {
@@ -30,7 +34,7 @@ func (arg Argument) ArangoID() string {
 
 func NewArgument(node DebateMapNode) Argument {
 	return Argument{
-		Key:       uuid.New().String(),
+		Key:       documentKey("arguments", node.ID),
 		ID:        node.ID,
 		CreatedAt: node.CreatedTime(),
 		Creator:   node.Creator,
@@ -38,7 +42,8 @@ func NewArgument(node DebateMapNode) Argument {
 		Negation:  node.Current.Title.Negation,
 		Question:  node.Current.Title.Question,
 		Note:      node.Note,
-		Relevance: 1.00,
-		Str:       0.50,
+		Relevance: ARGUMENT_DEFAULT_RELEVANCE,
+		Str:       ARGUMENT_DEFAULT_STRENGTH,
+		Terms:     node.Current.Title.AllTerms,
 	}
 }
@@ -13,3 +13,13 @@ type BaseClaim struct {
 	From      string    `json:"_from,omitempty"`
 	To        string    `json:"_to,omitempty"`
 }
+
+func NewBaseClaim(fromArg Argument, toid string) BaseClaim {
+	return BaseClaim{
+		Key:       edgeKey("base_claims", fromArg.ArangoID(), toid),
+		CreatedAt: fromArg.CreatedAt,
+		Creator:   fromArg.Creator,
+		From:      fromArg.ArangoID(),
+		To:        toid,
+	}
+}
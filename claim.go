@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/canonical-debate-lab/arango-importer/evaluation"
 )
 
-const PREMISE_RULE_NONE int = 0
-const PREMISE_RULE_ALL int = 1
-const PREMISE_RULE_ANY int = 2
-const PREMISE_RULE_ANY_TWO int = 3
+// The premise rules are interpreted by the evaluation package
+const PREMISE_RULE_NONE = evaluation.PREMISE_RULE_NONE
+const PREMISE_RULE_ALL = evaluation.PREMISE_RULE_ALL
+const PREMISE_RULE_ANY = evaluation.PREMISE_RULE_ANY
+const PREMISE_RULE_ANY_TWO = evaluation.PREMISE_RULE_ANY_TWO
+
+// The truth of a claim without ratings, before it is scored
+const CLAIM_DEFAULT_TRUTH = 0.50
 
 type Claim struct {
 	Key          string    `json:"_key"`
@@ -24,6 +28,8 @@ type Claim struct {
 	MultiPremise bool      `json:"mp"`
 	PremiseRule  int       `json:"mprule"`
 	Truth        float32   `json:"truth"`
+	Terms        []string  `json:"terms,omitempty"`
+	Language     string    `json:"lang"`
 }
 
 func (claim Claim) ArangoID() string {
@@ -32,7 +38,7 @@ func (claim Claim) ArangoID() string {
 
 func NewClaim(node DebateMapNode) Claim {
 	return Claim{
-		Key:          uuid.New().String(),
+		Key:          documentKey("claims", node.ID),
 		ID:           node.ID,
 		CreatedAt:    node.CreatedTime(),
 		Creator:      node.Creator,
@@ -42,7 +48,8 @@ func NewClaim(node DebateMapNode) Claim {
 		Note:         node.Note,
 		MultiPremise: node.MultiPremise,
 		PremiseRule:  argumentTypeToPremiseRule(node.Current.ArgumentType),
-		Truth:        0.50,
+		Truth:        CLAIM_DEFAULT_TRUTH,
+		Terms:        node.Current.Title.AllTerms,
 	}
 }
 
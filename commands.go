@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// Commands other than the import, which is what runs when no command is given:
+//
+//	go run *.go [import flags]
+//	go run *.go export --format aif -o debate.json
+var commands = map[string]func(args []string){
+	"evaluate": runEvaluate,
+	"export":   runExport,
+	"render":   runRender,
+	"schema":   runSchema,
+	"score":    runScore,
+
+	"suggest-merges": runSuggestMerges,
+}
+
+// ConnectionFlags are the flags shared by every command that talks to the database
+type ConnectionFlags struct {
+	Server   string
+	DBName   string
+	Username string
+	Password string
+}
+
+func (cf *ConnectionFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&cf.Server, "h", DEFAULT_SERVER, "host (e.g. http://localhost:8529)")
+	fs.StringVar(&cf.DBName, "db", DEFAULT_DB, "DB name")
+	fs.StringVar(&cf.Username, "u", DEFAULT_USERNAME, "username")
+	fs.StringVar(&cf.Password, "p", DEFAULT_PASSWORD, "password")
+}
+
+func (cf ConnectionFlags) Open() driver.Database {
+	db, _ := OpenArangoConnection(cf.Server, cf.DBName, cf.Username, cf.Password)
+	return db
+}
+
+// GraphSourceFlags choose where a command reads the graph from: an input file converted in memory
+// (as the import would write it), a JSONL dump directory, or else the database
+type GraphSourceFlags struct {
+	Filename        string
+	InputFormat     string
+	FirebaseVersion string
+	Dedup           string
+	DumpDir         string
+	Connection      ConnectionFlags
+}
+
+func (sf *GraphSourceFlags) Register(fs *flag.FlagSet) {
+	sf.Connection.Register(fs)
+	fs.StringVar(&sf.Filename, "f", "", "convert the graph from an input file (gzip, zstd and zip are decompressed; \"-\" reads stdin) instead of reading the database")
+	fs.StringVar(&sf.InputFormat, "input-format", FORMAT_AUTO_NAME, "format of the -f input ("+strings.Join(formatNameList(), ", ")+")")
+	fs.StringVar(&sf.FirebaseVersion, "fb-version", "", "version of a Firebase export to read with -f (default: the latest)")
+	fs.StringVar(&sf.Dedup, "dedup", DEDUP_OFF, "merge the claims with the same text when converting the -f input ("+strings.Join(dedupModes, ", ")+")")
+	fs.StringVar(&sf.DumpDir, "dump", "", "read the graph from a directory of JSONL files (claims.jsonl, arguments.jsonl, ...) instead of the database")
+}
+
+// LoadInput reads the Debate Map nodes of the -f input file, before any conversion
+func (sf GraphSourceFlags) LoadInput() DebateMapRoot {
+	format, err := parseFormatName(sf.InputFormat)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error in --input-format:", err.Error())
+		panic(err.Error())
+	}
+	return loadRoot(sf.Filename, InputOptions{Format: format, FirebaseVersion: sf.FirebaseVersion})
+}
+
+// Convert builds the graph from the Debate Map nodes of the input, merging the duplicate claims if asked to
+func (sf GraphSourceFlags) Convert(root DebateMapRoot) *Graph {
+	mode, err := parseDedupMode(sf.Dedup)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error in --dedup:", err.Error())
+		panic(err.Error())
+	}
+	g := buildGraph(root)
+	dedupClaims(g, mode)
+	return g
+}
+
+func (sf GraphSourceFlags) Load() *Graph {
+	if sf.Filename != "" {
+		return sf.Convert(sf.LoadInput())
+	}
+	var g *Graph
+	var err error
+	if sf.DumpDir != "" {
+		g, err = loadGraphFromDump(sf.DumpDir)
+	} else {
+		g, err = loadGraphFromDB(sf.Connection.Open())
+	}
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error reading the graph:", err.Error())
+		panic(err.Error())
+	}
+	return g
+}
+
+// logOutput receives the progress messages and warnings: the standard output,
+// unless a command writes its data there
+var logOutput io.Writer = os.Stdout
+
+// openOutput creates the named output file, or returns the standard output for "-".
+// When writing to the standard output, the progress messages are sent to the standard error instead,
+// so they don't end up mixed with the data.
+func openOutput(name string) *os.File {
+	if name == "" || name == "-" {
+		logOutput = os.Stderr
+		return os.Stdout
+	}
+	out, err := os.Create(name)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error creating output file:", err.Error())
+		panic(err.Error())
+	}
+	return out
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Columns of the CSV format. Only id, kind and title are required; the columns can be in any order.
+//
+//	id         unique ID of the row, which becomes the ID of the Claim or Argument
+//	parent_id  ID of the row this one belongs to (empty for root claims)
+//	kind       "claim" or "argument"
+//	polarity   "pro" or "con": whether an argument (or a claim directly under another claim)
+//	           supports or attacks its parent
+//	title      text of the claim or argument
+//	negation   negated form of the claim
+//	question   yes/no question form of the claim
+//	note       free-form note
+//	creator    ID of the author
+//	order      position among the rows with the same parent (e.g. the order of the premises)
+const CSV_COLUMN_ID = "id"
+const CSV_COLUMN_PARENT_ID = "parent_id"
+const CSV_COLUMN_KIND = "kind"
+const CSV_COLUMN_POLARITY = "polarity"
+const CSV_COLUMN_TITLE = "title"
+const CSV_COLUMN_NEGATION = "negation"
+const CSV_COLUMN_QUESTION = "question"
+const CSV_COLUMN_NOTE = "note"
+const CSV_COLUMN_CREATOR = "creator"
+const CSV_COLUMN_ORDER = "order"
+
+const CSV_KIND_CLAIM = "claim"
+const CSV_KIND_ARGUMENT = "argument"
+
+var csvColumns = []string{
+	CSV_COLUMN_ID, CSV_COLUMN_PARENT_ID, CSV_COLUMN_KIND, CSV_COLUMN_POLARITY, CSV_COLUMN_TITLE,
+	CSV_COLUMN_NEGATION, CSV_COLUMN_QUESTION, CSV_COLUMN_NOTE, CSV_COLUMN_CREATOR, CSV_COLUMN_ORDER,
+}
+var csvRequiredColumns = []string{CSV_COLUMN_ID, CSV_COLUMN_KIND, CSV_COLUMN_TITLE}
+
+type csvRow struct {
+	Number   int
+	ID       string
+	ParentID string
+	Kind     string
+	Polarity int
+	Order    int
+	Node     *DebateMapNode
+	// The polarity is invalid, and already reported
+	BadPolarity bool
+}
+
+// parseCSV converts a spreadsheet of claims and arguments into Debate Map nodes,
+// so the rest of the import can treat it like any other export:
+//   - A claim row without a parent is a root Claim
+//   - An argument row targets its parent, which can be a claim or another argument
+//   - A claim row under an argument is the base Claim of that argument. When an argument has several,
+//     it becomes a multi-premise argument (see ConvertToMPClaim), with the premises in their order.
+//   - A claim row under another claim supports or attacks it, and gets an intervening Argument,
+//     as in Debate Map data
+//
+// Every row is validated, and all the errors are reported together, with their line numbers in the file.
+func parseCSV(data []byte) (DebateMapRoot, error) {
+	root := DebateMapRoot{}
+	// The reader counts the lines from the first one it is given, after the blank lines trimInput removes
+	data = bytes.TrimPrefix(data, utf8BOM)
+	trimmed := trimInput(data)
+	skippedLines := bytes.Count(data[:bytes.Index(data, trimmed)], []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(trimmed))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return root, fmt.Errorf("error reading the CSV header: %s", err.Error())
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return root, fmt.Errorf("the CSV header has no %q column (expected columns: %s)", name, strings.Join(csvColumns, ", "))
+		}
+	}
+
+	type csvError struct {
+		row     int
+		message string
+	}
+	errors := []csvError{}
+	rowError := func(row int, format string, args ...interface{}) {
+		errors = append(errors, csvError{row: row, message: fmt.Sprintf("line %d: %s", row, fmt.Sprintf(format, args...))})
+	}
+
+	rows := []*csvRow{}
+	byID := map[string]*csvRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			if parseErr, ok := err.(*csv.ParseError); ok {
+				return root, fmt.Errorf("line %d: %s", parseErr.StartLine+skippedLines, parseErr.Err.Error())
+			}
+			return root, fmt.Errorf("error reading the CSV: %s", err.Error())
+		}
+		// A quoted field can span several lines, so the row is numbered by the line it starts on
+		line, _ := reader.FieldPos(0)
+		rowNumber := line + skippedLines
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		row := &csvRow{
+			Number:   rowNumber,
+			ID:       value(CSV_COLUMN_ID),
+			ParentID: value(CSV_COLUMN_PARENT_ID),
+			Kind:     strings.ToLower(value(CSV_COLUMN_KIND)),
+		}
+		if row.ID == "" {
+			rowError(rowNumber, "missing id")
+			continue
+		}
+		if other, ok := byID[row.ID]; ok {
+			rowError(rowNumber, "duplicate id %q (also on line %d)", row.ID, other.Number)
+			continue
+		}
+
+		switch strings.ToLower(value(CSV_COLUMN_POLARITY)) {
+		case "":
+		case "pro", "+":
+			row.Polarity = ARGUMENT_POLARITY_PRO
+		case "con", "-":
+			row.Polarity = ARGUMENT_POLARITY_CON
+		default:
+			rowError(rowNumber, "polarity must be pro or con, found %q", value(CSV_COLUMN_POLARITY))
+			row.BadPolarity = true
+		}
+		if order := value(CSV_COLUMN_ORDER); order != "" {
+			if row.Order, err = strconv.Atoi(order); err != nil {
+				rowError(rowNumber, "order must be a whole number, found %q", order)
+			}
+		}
+
+		row.Node = &DebateMapNode{
+			ID:      row.ID,
+			Creator: value(CSV_COLUMN_CREATOR),
+			Current: Current{
+				ID: row.ID,
+				Title: TitleSet{
+					Base:     value(CSV_COLUMN_TITLE),
+					Negation: value(CSV_COLUMN_NEGATION),
+					Question: value(CSV_COLUMN_QUESTION),
+				},
+			},
+			Note:     value(CSV_COLUMN_NOTE),
+			Parents:  map[string]interface{}{},
+			Children: map[string]interface{}{},
+		}
+		switch row.Kind {
+		case CSV_KIND_CLAIM:
+			row.Node.Type = NODE_TYPE_CLAIM
+			if row.Node.Current.Title.Base == "" {
+				rowError(rowNumber, "claim %q has no title", row.ID)
+			}
+		case CSV_KIND_ARGUMENT:
+			row.Node.Type = NODE_TYPE_ARGUMENT
+			row.Node.Polarity = row.Polarity
+			row.Node.Current.ArgumentType = ARGUMENT_TYPE_ALL
+			if row.ParentID == "" {
+				rowError(rowNumber, "argument %q has no parent_id", row.ID)
+			}
+			if row.Polarity == 0 && !row.BadPolarity {
+				rowError(rowNumber, "argument %q has no polarity", row.ID)
+			}
+		default:
+			rowError(rowNumber, "kind must be claim or argument, found %q", value(CSV_COLUMN_KIND))
+		}
+		rows = append(rows, row)
+		byID[row.ID] = row
+	}
+
+	// Hang each row under its parent, in order
+	children := map[string][]*csvRow{}
+	for _, row := range rows {
+		if row.ParentID == "" {
+			continue
+		}
+		parent, ok := byID[row.ParentID]
+		if !ok {
+			rowError(row.Number, "parent_id %q not found", row.ParentID)
+			continue
+		}
+		if parent.ID == row.ID {
+			rowError(row.Number, "row %q is its own parent", row.ID)
+			continue
+		}
+		if row.Kind == CSV_KIND_CLAIM && parent.Kind == CSV_KIND_CLAIM && row.Polarity == 0 && !row.BadPolarity {
+			rowError(row.Number, "claim %q under claim %q needs a polarity", row.ID, parent.ID)
+		}
+		children[parent.ID] = append(children[parent.ID], row)
+	}
+	if len(errors) > 0 {
+		sort.SliceStable(errors, func(i, j int) bool { return errors[i].row < errors[j].row })
+		messages := []string{}
+		for _, e := range errors {
+			messages = append(messages, e.message)
+		}
+		return root, fmt.Errorf("%d errors in the CSV rows:\n  %s", len(errors), strings.Join(messages, "\n  "))
+	}
+
+	for _, parent := range rows {
+		// The premises of an argument come first, so their position is their order
+		list := children[parent.ID]
+		sort.SliceStable(list, func(i, j int) bool {
+			if parent.Kind == CSV_KIND_ARGUMENT && list[i].Kind != list[j].Kind {
+				return list[i].Kind == CSV_KIND_CLAIM
+			}
+			return list[i].Order < list[j].Order
+		})
+		premises := 0
+		for _, row := range list {
+			polarity := row.Polarity
+			if parent.Kind == CSV_KIND_ARGUMENT && row.Kind == CSV_KIND_CLAIM {
+				// A base claim or premise of the argument
+				polarity = 0
+				premises++
+			}
+			parent.Node.Children[row.ID] = Child{ID: row.ID, Polarity: polarity}
+			parent.Node.ChildrenOrder = append(parent.Node.ChildrenOrder, row.ID)
+			row.Node.Parents[parent.ID] = true
+		}
+		parent.Node.MultiPremise = premises > 1
+	}
+
+	for _, row := range rows {
+		root.Nodes = append(root.Nodes, *row.Node)
+	}
+	fmt.Fprintf(logOutput, "Read %d CSV rows\n", len(rows))
+	return root, nil
+}
+
+// looksLikeCSV recognizes the CSV format by its header
+func looksLikeCSV(data []byte) bool {
+	header, err := csv.NewReader(bytes.NewReader(trimInput(data))).Read()
+	if err != nil {
+		return false
+	}
+	found := map[string]bool{}
+	for _, name := range header {
+		found[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	for _, name := range csvRequiredColumns {
+		if !found[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testCSV = `id,parent_id,kind,polarity,title,negation,question,note,creator,order
+t,,claim,,Brazil should reform pensions,,Should Brazil reform pensions?,,alice,
+a1,t,argument,pro,,,,,alice,1
+c1,a1,claim,,The deficit keeps growing,,,,bob,
+a2,t,argument,con,Demographics,,,,bob,2
+p2,a2,claim,,Fewer workers,,,,bob,2
+p1,a2,claim,,People live longer,,,,bob,1
+c3,t,claim,pro,Other countries did it,,,,carol,3
+`
+
+func TestParseCSV(t *testing.T) {
+	root, err := parseCSV([]byte(testCSV))
+	if err != nil {
+		t.Fatalf("parseCSV: %s", err.Error())
+	}
+	nodes := map[string]DebateMapNode{}
+	for _, node := range root.Nodes {
+		nodes[node.ID] = node
+	}
+	if thesis := nodes["t"]; thesis.Type != NODE_TYPE_CLAIM || thesis.Creator != "alice" || thesis.Current.Title.Question != "Should Brazil reform pensions?" {
+		t.Errorf("unexpected root claim: %+v", thesis)
+	}
+	if order := nodes["t"].ChildrenOrder; !reflect.DeepEqual(order, []string{"a1", "a2", "c3"}) {
+		t.Errorf("the root claim has the children %v, expected a1, a2 and c3", order)
+	}
+	for id, polarity := range map[string]int{"a1": ARGUMENT_POLARITY_PRO, "a2": ARGUMENT_POLARITY_CON, "c3": ARGUMENT_POLARITY_PRO} {
+		if child := NewChildFromData(id, nodes["t"].Children[id]); child == nil || child.Polarity != polarity {
+			t.Errorf("%s is not a child of the root claim with polarity %d", id, polarity)
+		}
+	}
+
+	// The premises are in their order, not in the order of the rows
+	if a2 := nodes["a2"]; !a2.MultiPremise || !reflect.DeepEqual(a2.ChildrenOrder, []string{"p1", "p2"}) {
+		t.Errorf("a2 is multi-premise: %v, with the premises %v, expected p1 and p2", a2.MultiPremise, a2.ChildrenOrder)
+	}
+	if a1 := nodes["a1"]; a1.MultiPremise || !reflect.DeepEqual(a1.ChildrenOrder, []string{"c1"}) {
+		t.Errorf("a1 is multi-premise: %v, with the premises %v, expected c1", a1.MultiPremise, a1.ChildrenOrder)
+	}
+
+	g := buildGraph(root)
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+	// a1, a2 and the intervening argument of c3
+	if len(g.Arguments) != 3 || len(g.Premises) != 2 {
+		t.Errorf("built %d arguments and %d premises, expected 3 and 2", len(g.Arguments), len(g.Premises))
+	}
+}
+
+func TestParseCSVColumns(t *testing.T) {
+	// The columns can be in any order and case, and only id, kind and title are required
+	root, err := parseCSV([]byte("Title, KIND, Id\nBrazil should reform pensions, Claim, t\n"))
+	if err != nil {
+		t.Fatalf("parseCSV: %s", err.Error())
+	}
+	if len(root.Nodes) != 1 || root.Nodes[0].ID != "t" || root.Nodes[0].Current.Title.Base != "Brazil should reform pensions" {
+		t.Errorf("unexpected nodes: %+v", root.Nodes)
+	}
+
+	if _, err := parseCSV([]byte("id,parent_id,kind\nt,,claim\n")); err == nil || !strings.Contains(err.Error(), `no "title" column`) {
+		t.Errorf("parseCSV without a title column returned the error %v", err)
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	_, err := parseCSV([]byte(`id,parent_id,kind,polarity,title,order
+x,,claim,,,
+y,zz,argument,maybe,,abc
+x,,thing,,Foo,
+c,x,claim,,Under a claim,
+`))
+	if err == nil {
+		t.Fatalf("parseCSV accepted the invalid rows")
+	}
+	// Every error is reported, by line, and the invalid polarity only once
+	expected := []string{
+		"6 errors in the CSV rows:",
+		`line 2: claim "x" has no title`,
+		`line 3: polarity must be pro or con, found "maybe"`,
+		`line 3: order must be a whole number, found "abc"`,
+		`line 3: parent_id "zz" not found`,
+		`line 4: duplicate id "x" (also on line 2)`,
+		`line 5: claim "c" under claim "x" needs a polarity`,
+	}
+	for _, message := range expected {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("the error doesn't report %q:\n%s", message, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "has no polarity") {
+		t.Errorf("the invalid polarity is also reported as missing:\n%s", err.Error())
+	}
+}
+
+func TestParseCSVLines(t *testing.T) {
+	// A title spanning two lines, after blank lines, moves the rows after it
+	_, err := parseCSV([]byte(`
+
+id,parent_id,kind,title
+t,,claim,"Brazil should
+reform pensions"
+a,t,argument,
+`))
+	if err == nil || !strings.Contains(err.Error(), `line 6: argument "a" has no polarity`) {
+		t.Errorf("parseCSV returned the error %v, expected one on line 6", err)
+	}
+
+	_, err = parseCSV([]byte("id,kind,title\nt,claim,\"Brazil\nu,claim,Other\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("parseCSV returned the error %v, expected one on line 2", err)
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 const NODE_TYPE_CATEGORY int = 10
@@ -19,10 +21,21 @@ const ARGUMENT_TYPE_ANY int = 10
 const ARGUMENT_TYPE_ANY_TWO int = 15
 const ARGUMENT_TYPE_ALL int = 20
 
+// Suffixes added to the ID of a node to get the ID of the Claims created from it
+const MP_CLAIM_ID_SUFFIX = "-mp"
+const CONVERTED_CLAIM_ID_SUFFIX = "-claim"
+
+// Separator of the parent and child IDs in the ID of an intervening Argument,
+// created for a claim found directly under another claim
+const INTERVENING_ARGUMENT_ID_SEPARATOR = "->"
+
 type DebateMapRoot struct {
-	Maps          []DebateMapMap  `json:"maps"`
-	Nodes         []DebateMapNode `json:"nodes"`
-	NodeRevisions []NodeRevision  `json:"nodeRevisions"`
+	Maps          []DebateMapMap        `json:"maps"`
+	Nodes         []DebateMapNode       `json:"nodes"`
+	NodeRevisions []NodeRevision        `json:"nodeRevisions"`
+	NodeRatings   []NodeRatings         `json:"nodeRatings"`
+	Users         []DebateMapUser       `json:"users"`
+	UserExtras    []DebateMapUserExtras `json:"userExtras"`
 }
 
 type DebateMapNode struct {
@@ -93,7 +106,7 @@ func (node DebateMapNode) ConvertToMPClaim() (newArg, newClaim DebateMapNode) {
 	}
 
 	newClaim = DebateMapNode{
-		ID:            uuid.New().String(),
+		ID:            node.ID + MP_CLAIM_ID_SUFFIX,
 		CreatedAt:     node.CreatedAt,
 		Creator:       node.Creator,
 		Type:          NODE_TYPE_CLAIM,
@@ -114,7 +127,7 @@ func (node DebateMapNode) ConvertToMPClaim() (newArg, newClaim DebateMapNode) {
 // If the node is a root node, then the "argument" will be nil
 func (node DebateMapNode) ConvertToClaimAndArg() (newArg *DebateMapNode, newClaim DebateMapNode) {
 	newClaim = DebateMapNode{
-		ID:            uuid.New().String(),
+		ID:            node.ID + CONVERTED_CLAIM_ID_SUFFIX,
 		CreatedAt:     node.CreatedAt,
 		Creator:       node.Creator,
 		Type:          NODE_TYPE_CLAIM,
@@ -152,14 +165,44 @@ type Current struct {
 }
 
 type NodeRevision struct {
-	ID    string   `json:"_key"`
-	Title TitleSet `json:"titles"`
+	ID           string   `json:"_key"`
+	Title        TitleSet `json:"titles"`
+	ArgumentType int      `json:"argumentType"`
 }
 
 type TitleSet struct {
-	Base     string `json:"base"`
-	Negation string `json:"negation"`
-	Question string `json:"yesNoQuestion"`
+	Base     string  `json:"base"`
+	Negation string  `json:"negation"`
+	Question string  `json:"yesNoQuestion"`
+	AllTerms TermSet `json:"allTerms"`
+}
+
+// TermSet is the allTerms of a title: the lowercase words of the base title, tokenized by Debate Map.
+// The export stores them as the keys of an object ({"climate": true, ..., "_key": "allTerms"});
+// they are kept sorted, without the "_key".
+// When all the terms are numbers, Firebase exports the object as an array, with the terms as indexes.
+type TermSet []string
+
+func (ts *TermSet) UnmarshalJSON(data []byte) error {
+	var terms map[string]interface{}
+	if err := json.Unmarshal(data, &terms); err != nil {
+		var indexed []interface{}
+		if err := json.Unmarshal(data, &indexed); err != nil {
+			return fmt.Errorf("allTerms must be an object or an array, found %s", string(data))
+		}
+		terms = map[string]interface{}{}
+		for i, value := range indexed {
+			terms[strconv.Itoa(i)] = value
+		}
+	}
+	*ts = TermSet{}
+	for term, value := range terms {
+		if set, ok := value.(bool); ok && set && term != "_key" {
+			*ts = append(*ts, term)
+		}
+	}
+	sort.Strings(*ts)
+	return nil
 }
 
 type Child struct {
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// How claims with the same text are merged into one canonical claim:
+// not at all, when their titles are identical, or when they are identical once normalized (see normalizeText)
+const DEDUP_OFF = "off"
+const DEDUP_EXACT = "exact"
+const DEDUP_NORMALIZED = "normalized"
+
+var dedupModes = []string{DEDUP_OFF, DEDUP_EXACT, DEDUP_NORMALIZED}
+
+func parseDedupMode(mode string) (string, error) {
+	for _, m := range dedupModes {
+		if m == mode {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown dedup mode %q (expected one of: %s)", mode, strings.Join(dedupModes, ", "))
+}
+
+// A ClaimMerge is a claim that was merged into the canonical claim with the same text
+type ClaimMerge struct {
+	Canonical Claim
+	Duplicate Claim
+}
+
+// normalizeText folds the differences that don't change what a statement says:
+// Unicode compatibility forms (NFKC), case, punctuation and whitespace
+func normalizeText(text string) string {
+	text = strings.ToLower(norm.NFKC.String(text))
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// mergeable tells whether a claim stands for a statement that could be written elsewhere too.
+// Multi-premise claims are defined by their premises rather than their text, and the claims converted
+// from categories, packages and questions are placeholders (e.g. "Root" in every map).
+func mergeable(claim Claim) bool {
+	return !claim.MultiPremise && !strings.HasSuffix(claim.ID, CONVERTED_CLAIM_ID_SUFFIX) && claim.Title != ""
+}
+
+func dedupText(claim Claim, mode string) string {
+	if mode == DEDUP_NORMALIZED {
+		return normalizeText(claim.Title)
+	}
+	return claim.Title
+}
+
+// dedupClaims merges the claims with the same text into the first of them, and repoints the edges to it:
+// the arguments based on the duplicates, the multi-premise claims having them as premises,
+// the arguments targeting them, and their ratings and authors. Edges that become identical are kept once.
+// Only mergeable claims are merged.
+func dedupClaims(g *Graph, mode string) []ClaimMerge {
+	if mode == DEDUP_OFF {
+		return nil
+	}
+
+	canonicalByText := map[string]Claim{}
+	replaced := map[string]Claim{}
+	merges := []ClaimMerge{}
+	claims := []Claim{}
+	for _, claim := range g.Claims {
+		text := dedupText(claim, mode)
+		if !mergeable(claim) || text == "" {
+			claims = append(claims, claim)
+			continue
+		}
+		canonical, ok := canonicalByText[text]
+		if !ok {
+			canonicalByText[text] = claim
+			claims = append(claims, claim)
+			continue
+		}
+		replaced[claim.ArangoID()] = canonical
+		merges = append(merges, ClaimMerge{Canonical: canonical, Duplicate: claim})
+	}
+	if len(merges) == 0 {
+		fmt.Fprintln(logOutput, "No duplicate claims")
+		return merges
+	}
+	g.Claims = claims
+
+	replacedIDs := map[string]string{}
+	for _, merge := range merges {
+		replacedIDs[merge.Duplicate.ID] = merge.Canonical.ID
+	}
+	for i, arg := range g.Arguments {
+		if arg.TargetClaimID != nil {
+			if id, ok := replacedIDs[*arg.TargetClaimID]; ok {
+				g.Arguments[i].TargetClaimID = &id
+			}
+		}
+		if id, ok := replacedIDs[arg.ClaimID]; ok {
+			g.Arguments[i].ClaimID = id
+		}
+		if arg.TargetClaimID != nil && g.Arguments[i].ClaimID == *g.Arguments[i].TargetClaimID {
+			fmt.Fprintf(logOutput, "----------------------------Argument %s is now based on the claim %s it targets\n", arg.ID, g.Arguments[i].ClaimID)
+		}
+	}
+
+	repoint := func(handle string) string {
+		if canonical, ok := replaced[handle]; ok {
+			return canonical.ArangoID()
+		}
+		return handle
+	}
+	seen := map[string]bool{}
+	inferences := []Inference{}
+	for _, inference := range g.Inferences {
+		inference.From = repoint(inference.From)
+		inference.Key = edgeKey("inferences", inference.From, inference.To)
+		if !seen[inference.Key] {
+			seen[inference.Key] = true
+			inferences = append(inferences, inference)
+		}
+	}
+	g.Inferences = inferences
+	baseClaims := []BaseClaim{}
+	for _, bc := range g.BaseClaims {
+		bc.To = repoint(bc.To)
+		bc.Key = edgeKey("base_claims", bc.From, bc.To)
+		if !seen[bc.Key] {
+			seen[bc.Key] = true
+			baseClaims = append(baseClaims, bc)
+		}
+	}
+	g.BaseClaims = baseClaims
+	premises := []Premise{}
+	for _, premise := range g.Premises {
+		premise.To = repoint(premise.To)
+		premise.Key = edgeKey("premises", premise.From, premise.To)
+		if !seen[premise.Key] {
+			seen[premise.Key] = true
+			premises = append(premises, premise)
+		}
+	}
+	g.Premises = premises
+	for i, edge := range g.RatingTargets {
+		g.RatingTargets[i].To = repoint(edge.To)
+		g.RatingTargets[i].Key = edgeKey("rating_targets", edge.From, g.RatingTargets[i].To)
+	}
+	creations := []Creation{}
+	for _, creation := range g.Creations {
+		creation.To = repoint(creation.To)
+		creation.Key = edgeKey("created", creation.From, creation.To)
+		if !seen[creation.Key] {
+			seen[creation.Key] = true
+			creations = append(creations, creation)
+		}
+	}
+	g.Creations = creations
+	// The canonical claims now have the ratings of their duplicates as well
+	applyRatings(g)
+
+	for _, merge := range merges {
+		fmt.Fprintf(logOutput, "Merged claim %s into %s: %q\n", merge.Duplicate.ID, merge.Canonical.ID, merge.Canonical.Title)
+	}
+	fmt.Fprintf(logOutput, "Merged %d duplicate claims (%s)\n", len(merges), mode)
+	return merges
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := map[string]string{
+		"Nuclear emits little CO2.":        "nuclear emits little co2",
+		"  nuclear   emits little CO₂ !  ": "nuclear emits little co2",
+		"Ｎｕｃｌｅａｒ, emits—little CO2":        "nuclear emits little co2",
+		"O déficit está crescendo.":        "o déficit está crescendo",
+	}
+	for text, expected := range tests {
+		if normalized := normalizeText(text); normalized != expected {
+			t.Errorf("normalizeText(%q) = %q, expected %q", text, normalized, expected)
+		}
+	}
+}
+
+// dedupRoot has a claim under another claim twice, once with different punctuation, and a rating of the second
+func dedupRoot() DebateMapRoot {
+	return DebateMapRoot{
+		Nodes: []DebateMapNode{
+			claimNode("R", "Nuclear is clean.", map[string]interface{}{
+				"D1": map[string]interface{}{"polarity": float64(ARGUMENT_POLARITY_PRO)},
+				"D2": map[string]interface{}{"polarity": float64(ARGUMENT_POLARITY_CON)},
+			}),
+			claimNode("D1", "Nuclear emits little CO2.", map[string]interface{}{}),
+			claimNode("D2", "nuclear emits little CO2!", map[string]interface{}{}),
+		},
+		NodeRatings: []NodeRatings{{ID: "D2", Ratings: []NodeRating{{Type: "truth", User: "u1", Value: 20}}}},
+	}
+}
+
+func TestDedupClaims(t *testing.T) {
+	g := buildGraph(dedupRoot())
+	if merges := dedupClaims(g, DEDUP_EXACT); len(merges) != 0 || len(g.Claims) != 3 {
+		t.Fatalf("exact dedup merged %d claims, expected none", len(merges))
+	}
+
+	merges := dedupClaims(g, DEDUP_NORMALIZED)
+	if len(merges) != 1 || merges[0].Canonical.ID != "D1" || merges[0].Duplicate.ID != "D2" {
+		t.Fatalf("normalized dedup merged %+v, expected D2 into D1", merges)
+	}
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+	if err := checkUniqueIndexes(g); err != nil {
+		t.Fatalf("checkUniqueIndexes: %s", err.Error())
+	}
+
+	claims := map[string]Claim{}
+	for _, claim := range g.Claims {
+		claims[claim.ID] = claim
+	}
+	if _, ok := claims["D2"]; ok || len(g.Claims) != 2 {
+		t.Errorf("the claims are %v after the merge, expected R and D1", g.Claims)
+	}
+	// Both arguments are now based on D1, which has the rating of D2
+	for _, arg := range g.Arguments {
+		if arg.ClaimID != "D1" {
+			t.Errorf("argument %s is based on %s, expected D1", arg.ID, arg.ClaimID)
+		}
+	}
+	for _, bc := range g.BaseClaims {
+		if bc.To != claims["D1"].ArangoID() {
+			t.Errorf("base claim edge %s points to %s, expected D1", bc.Key, bc.To)
+		}
+	}
+	if len(g.RatingTargets) != 1 || g.RatingTargets[0].To != claims["D1"].ArangoID() || claims["D1"].Truth != 0.2 {
+		t.Errorf("the rating of D2 was not moved to D1: %+v, truth %v", g.RatingTargets, claims["D1"].Truth)
+	}
+}
+
+func TestMergeable(t *testing.T) {
+	if mergeable(Claim{ID: "A" + MP_CLAIM_ID_SUFFIX, Title: "Nuclear is clean.", MultiPremise: true}) {
+		t.Errorf("a multi-premise claim is mergeable")
+	}
+	if mergeable(Claim{ID: "Root" + CONVERTED_CLAIM_ID_SUFFIX, Title: "Root"}) {
+		t.Errorf("a claim converted from a category is mergeable")
+	}
+	if !mergeable(Claim{ID: "A", Title: "Nuclear is clean."}) {
+		t.Errorf("a claim is not mergeable")
+	}
+}
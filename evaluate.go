@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/canonical-debate-lab/arango-importer/evaluation"
+)
+
+// runEvaluate combines the premises of the multi-premise claims under each premise rule, with their current truths,
+// and checks that the claims are structured as Debate Map intended:
+//
+//	go run *.go evaluate [--claim Ikan0wFzSXm7GYSPvglJ3A-mp]
+func runEvaluate(args []string) {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	source := GraphSourceFlags{}
+	source.Register(fs)
+	var claimID, output string
+	fs.StringVar(&claimID, "claim", "", "ID or _key of the multi-premise claim to evaluate (default: all of them)")
+	fs.StringVar(&output, "o", "-", "output file (\"-\" for stdout)")
+	fs.Parse(args)
+
+	out := openOutput(output)
+	defer out.Close()
+
+	g := source.Load()
+	evaluated, problems, err := writeEvaluations(out, g, claimID)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error evaluating the claims:", err.Error())
+		panic(err.Error())
+	}
+	if claimID != "" && evaluated == 0 {
+		fmt.Fprintln(logOutput, "Error in --claim: no multi-premise claim", claimID)
+		panic("claim not found: " + claimID)
+	}
+	fmt.Fprintf(logOutput, "Evaluated %d multi-premise claims, %d problems\n", evaluated, problems)
+	fmt.Fprintln(logOutput, "Done.")
+}
+
+// writeEvaluations writes the evaluation of each multi-premise claim (or of the one given),
+// and returns how many claims were evaluated and how many problems were found
+func writeEvaluations(w io.Writer, g *Graph, claimID string) (int, int, error) {
+	claims := g.ClaimsByArangoID()
+	premisesByClaim := g.PremisesByClaim()
+	evaluated, problems := 0, 0
+	for _, claim := range g.Claims {
+		if claimID != "" && claim.ID != claimID && claim.Key != claimID {
+			continue
+		}
+		claimPremises := premisesByClaim[claim.ArangoID()]
+		if !claim.MultiPremise && len(claimPremises) == 0 {
+			continue
+		}
+		evaluated++
+
+		premises := []evaluation.Premise{}
+		titles := []string{}
+		for _, premise := range claimPremises {
+			premiseClaim, ok := claims[premise.To]
+			if !ok {
+				return evaluated, problems, fmt.Errorf("premise %s of claim %s is not in the graph", premise.To, claim.ID)
+			}
+			premises = append(premises, evaluation.Premise{Order: premise.Order, Truth: float64(premiseClaim.Truth)})
+			titles = append(titles, premiseClaim.Title)
+		}
+		result, err := evaluation.Evaluate(premises)
+		if err != nil {
+			return evaluated, problems, fmt.Errorf("claim %s: %s", claim.ID, err.Error())
+		}
+		found := evaluation.Check(claim.PremiseRule, premises)
+		if !claim.MultiPremise {
+			found = append(found, "has premises but is not multi-premise")
+		}
+		problems += len(found)
+
+		fmt.Fprintf(w, "claim %s %q, rule %s\n", claim.ID, claim.Title, evaluation.RuleName(claim.PremiseRule))
+		// PremisesByClaim and Evaluate both keep the premises in their order, so the titles line up
+		for i, premise := range result.Premises {
+			fmt.Fprintf(w, "  %d. truth %.3f %q\n", premise.Order, premise.Truth, titles[i])
+		}
+		for _, rule := range evaluation.Rules {
+			marker := ""
+			if rule == claim.PremiseRule || (rule == PREMISE_RULE_ALL && claim.PremiseRule == PREMISE_RULE_NONE) {
+				marker = " <-"
+			}
+			fmt.Fprintf(w, "  %-7s %.3f%s\n", evaluation.RuleName(rule), result.Truths[rule], marker)
+		}
+		for _, problem := range found {
+			fmt.Fprintf(w, "  problem: %s\n", problem)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return evaluated, problems, err
+		}
+	}
+	return evaluated, problems, nil
+}
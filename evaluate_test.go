@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteEvaluations(t *testing.T) {
+	g := testGraph(t)
+	b := &bytes.Buffer{}
+	evaluated, problems, err := writeEvaluations(b, g, "")
+	if err != nil {
+		t.Fatalf("writeEvaluations: %s", err.Error())
+	}
+	if evaluated != 1 || problems != 0 {
+		t.Errorf("evaluated %d claims with %d problems, expected 1 and 0:\n%s", evaluated, problems, b.String())
+	}
+	// The premises start with a truth of 0.5, and the claim of an argument of type ALL has the rule ALL
+	for _, line := range []string{
+		`claim A` + MP_CLAIM_ID_SUFFIX + ` "Nuclear power is cleaner and cheaper than coal power in the long run.", rule ALL`,
+		`  1. truth 0.500 "Nuclear power plants emit far less carbon dioxide than coal power plants."`,
+		`  ALL     0.250 <-`,
+		`  ANY     0.750`,
+		`  ANY_TWO 0.250`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("missing the line %q in:\n%s", line, b.String())
+		}
+	}
+
+	if evaluated, _, _ := writeEvaluations(&bytes.Buffer{}, g, "P1"); evaluated != 0 {
+		t.Errorf("evaluated %d claims for the premise P1, expected none", evaluated)
+	}
+}
@@ -0,0 +1,143 @@
+// Package evaluation interprets the premise rules of multi-premise claims: how the truths of the premises
+// combine into the truth of the claim. It knows nothing of the database, so server-api can use it as well.
+package evaluation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The premise rules, with the values stored in the "mprule" field of a claim.
+// A multi-premise claim is true if all of its premises are (ALL), if any of them is (ANY),
+// or if at least two of them are (ANY_TWO). NONE is for claims that are not multi-premise.
+const PREMISE_RULE_NONE int = 0
+const PREMISE_RULE_ALL int = 1
+const PREMISE_RULE_ANY int = 2
+const PREMISE_RULE_ANY_TWO int = 3
+
+// Rules lists the rules a multi-premise claim can have, in the order of their values
+var Rules = []int{PREMISE_RULE_ALL, PREMISE_RULE_ANY, PREMISE_RULE_ANY_TWO}
+
+func RuleName(rule int) string {
+	switch rule {
+	case PREMISE_RULE_NONE:
+		return "NONE"
+	case PREMISE_RULE_ALL:
+		return "ALL"
+	case PREMISE_RULE_ANY:
+		return "ANY"
+	case PREMISE_RULE_ANY_TWO:
+		return "ANY_TWO"
+	default:
+		return fmt.Sprintf("unknown (%d)", rule)
+	}
+}
+
+// A Premise is one of the premises of a multi-premise claim: its position (Premise.Order in the graph)
+// and the probability that it is true, from 0 to 1
+type Premise struct {
+	Order int
+	Truth float64
+}
+
+// SortPremises orders the premises by their Order, as Debate Map lists them
+func SortPremises(premises []Premise) []Premise {
+	sorted := append([]Premise{}, premises...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+	return sorted
+}
+
+// Combine computes the truth of a multi-premise claim under the rule, taking its premises as independent.
+// A claim without a rule needs all of its premises, as in Debate Map.
+func Combine(rule int, premises []Premise) (float64, error) {
+	for _, premise := range premises {
+		if premise.Truth < 0 || premise.Truth > 1 {
+			return 0, fmt.Errorf("premise %d has a truth of %v, outside of 0 to 1", premise.Order, premise.Truth)
+		}
+	}
+	switch rule {
+	case PREMISE_RULE_NONE, PREMISE_RULE_ALL:
+		return all(premises), nil
+	case PREMISE_RULE_ANY:
+		return 1 - none(premises), nil
+	case PREMISE_RULE_ANY_TWO:
+		return 1 - none(premises) - exactlyOne(premises), nil
+	default:
+		return 0, fmt.Errorf("unknown premise rule %d", rule)
+	}
+}
+
+// An Evaluation is the truth of a multi-premise claim under each of the rules
+type Evaluation struct {
+	Premises []Premise
+	Truths   map[int]float64
+}
+
+// Evaluate combines the premises under every rule, with the premises in their order
+func Evaluate(premises []Premise) (Evaluation, error) {
+	evaluation := Evaluation{Premises: SortPremises(premises), Truths: map[int]float64{}}
+	for _, rule := range Rules {
+		truth, err := Combine(rule, evaluation.Premises)
+		if err != nil {
+			return evaluation, err
+		}
+		evaluation.Truths[rule] = truth
+	}
+	return evaluation, nil
+}
+
+// Check lists what doesn't match how Debate Map uses the rule: a multi-premise claim needs a rule,
+// ANY_TWO needs at least two premises, and the premises are numbered 1, 2, 3... in order.
+func Check(rule int, premises []Premise) []string {
+	problems := []string{}
+	if len(premises) == 0 {
+		problems = append(problems, "has no premises")
+	}
+	switch rule {
+	case PREMISE_RULE_NONE:
+		problems = append(problems, "has no premise rule (ALL is assumed)")
+	case PREMISE_RULE_ALL, PREMISE_RULE_ANY:
+	case PREMISE_RULE_ANY_TWO:
+		if len(premises) < 2 {
+			problems = append(problems, fmt.Sprintf("needs two true premises (ANY_TWO), but has %d", len(premises)))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("has an unknown premise rule %d", rule))
+	}
+	for i, premise := range SortPremises(premises) {
+		if premise.Order != i+1 {
+			problems = append(problems, fmt.Sprintf("premise %d of %d has the order %d", i+1, len(premises), premise.Order))
+		}
+	}
+	return problems
+}
+
+func all(premises []Premise) float64 {
+	p := 1.0
+	for _, premise := range premises {
+		p *= premise.Truth
+	}
+	return p
+}
+
+func none(premises []Premise) float64 {
+	p := 1.0
+	for _, premise := range premises {
+		p *= 1 - premise.Truth
+	}
+	return p
+}
+
+func exactlyOne(premises []Premise) float64 {
+	sum := 0.0
+	for i, premise := range premises {
+		p := premise.Truth
+		for j, other := range premises {
+			if j != i {
+				p *= 1 - other.Truth
+			}
+		}
+		sum += p
+	}
+	return sum
+}
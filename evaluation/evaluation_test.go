@@ -0,0 +1,82 @@
+package evaluation
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	premises := []Premise{{Order: 1, Truth: 0.9}, {Order: 2, Truth: 0.8}, {Order: 3, Truth: 0.5}}
+	tests := []struct {
+		Rule     int
+		Premises []Premise
+		Truth    float64
+	}{
+		{PREMISE_RULE_ALL, premises, 0.9 * 0.8 * 0.5},
+		// A claim without a rule needs all of its premises
+		{PREMISE_RULE_NONE, premises, 0.9 * 0.8 * 0.5},
+		{PREMISE_RULE_ANY, premises, 1 - 0.1*0.2*0.5},
+		// At least two: neither none nor exactly one of them
+		{PREMISE_RULE_ANY_TWO, premises, 1 - 0.1*0.2*0.5 - (0.9*0.2*0.5 + 0.1*0.8*0.5 + 0.1*0.2*0.5)},
+		{PREMISE_RULE_ANY_TWO, []Premise{{Order: 1, Truth: 1}}, 0},
+		{PREMISE_RULE_ALL, []Premise{{Order: 1, Truth: 1}, {Order: 2, Truth: 0}}, 0},
+		{PREMISE_RULE_ANY, []Premise{{Order: 1, Truth: 1}, {Order: 2, Truth: 0}}, 1},
+	}
+	for _, test := range tests {
+		truth, err := Combine(test.Rule, test.Premises)
+		if err != nil {
+			t.Errorf("Combine(%s, %v): %s", RuleName(test.Rule), test.Premises, err.Error())
+			continue
+		}
+		if math.Abs(truth-test.Truth) > 1e-9 {
+			t.Errorf("Combine(%s, %v) = %v, expected %v", RuleName(test.Rule), test.Premises, truth, test.Truth)
+		}
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	if _, err := Combine(PREMISE_RULE_ALL, []Premise{{Order: 1, Truth: 1.5}}); err == nil || !strings.Contains(err.Error(), "premise 1") {
+		t.Errorf("Combine accepted a truth of 1.5, with the error %v", err)
+	}
+	if _, err := Combine(7, []Premise{{Order: 1, Truth: 0.5}}); err == nil || !strings.Contains(err.Error(), "unknown premise rule 7") {
+		t.Errorf("Combine accepted the rule 7, with the error %v", err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	evaluation, err := Evaluate([]Premise{{Order: 2, Truth: 0.5}, {Order: 1, Truth: 0.5}})
+	if err != nil {
+		t.Fatalf("Evaluate: %s", err.Error())
+	}
+	if orders := []int{evaluation.Premises[0].Order, evaluation.Premises[1].Order}; !reflect.DeepEqual(orders, []int{1, 2}) {
+		t.Errorf("Evaluate kept the premises in the order %v, expected 1, 2", orders)
+	}
+	expected := map[int]float64{PREMISE_RULE_ALL: 0.25, PREMISE_RULE_ANY: 0.75, PREMISE_RULE_ANY_TWO: 0.25}
+	if !reflect.DeepEqual(evaluation.Truths, expected) {
+		t.Errorf("Evaluate found the truths %v, expected %v", evaluation.Truths, expected)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	two := []Premise{{Order: 1, Truth: 0.5}, {Order: 2, Truth: 0.5}}
+	tests := []struct {
+		Rule     int
+		Premises []Premise
+		Problems []string
+	}{
+		{PREMISE_RULE_ALL, two, []string{}},
+		{PREMISE_RULE_ANY_TWO, two, []string{}},
+		{PREMISE_RULE_NONE, two, []string{"has no premise rule (ALL is assumed)"}},
+		{PREMISE_RULE_ANY_TWO, two[:1], []string{"needs two true premises (ANY_TWO), but has 1"}},
+		{PREMISE_RULE_ANY, nil, []string{"has no premises"}},
+		{9, two, []string{"has an unknown premise rule 9"}},
+		{PREMISE_RULE_ALL, []Premise{{Order: 1}, {Order: 3}}, []string{"premise 2 of 2 has the order 3"}},
+	}
+	for _, test := range tests {
+		if problems := Check(test.Rule, test.Premises); !reflect.DeepEqual(problems, test.Problems) {
+			t.Errorf("Check(%s, %v) = %q, expected %q", RuleName(test.Rule), test.Premises, problems, test.Problems)
+		}
+	}
+}
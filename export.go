@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Exporters write the graph in one of the supported output formats
+var exporters = map[string]func(w io.Writer, g *Graph) error{
+	"aif":     writeAIF,
+	"argdown": writeArgdown,
+	"cypher":  writeCypher,
+	"gexf":    writeGEXF,
+	"graphml": writeGraphML,
+}
+
+func exporterNames() []string {
+	names := []string{}
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runExport reads the graph from the database (or a JSONL dump, or an input file) and writes it in another format
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	source := GraphSourceFlags{}
+	source.Register(fs)
+	anonymize := AnonymizeFlags{}
+	anonymize.Register(fs)
+	var format, output string
+	fs.StringVar(&format, "format", "aif", "output format ("+strings.Join(exporterNames(), ", ")+")")
+	fs.StringVar(&output, "o", "-", "output file (\"-\" for stdout)")
+	fs.Parse(args)
+
+	exporter, ok := exporters[strings.ToLower(format)]
+	if !ok {
+		err := fmt.Errorf("unknown export format %q (expected one of: %s)", format, strings.Join(exporterNames(), ", "))
+		fmt.Fprintln(logOutput, "Error in --format:", err.Error())
+		panic(err.Error())
+	}
+	if err := anonymize.Check(); err != nil {
+		fmt.Fprintln(logOutput, "Error in --anonymize:", err.Error())
+		panic(err.Error())
+	}
+
+	out := openOutput(output)
+	defer out.Close()
+
+	g := source.Load()
+	anonymize.Apply(g)
+	fmt.Fprintf(logOutput, "Exporting the graph in %s format\n", strings.ToUpper(format))
+	if err := exporter(out, g); err != nil {
+		fmt.Fprintln(logOutput, "Error exporting the graph:", err.Error())
+		panic(err.Error())
+	}
+	fmt.Fprintln(logOutput, "Done.")
+}
+
+func writeAIF(w io.Writer, g *Graph) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(exportAIF(g))
+}
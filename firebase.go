@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FirebaseExport is the layout of a raw Firebase Realtime Database export,
+// where each collection is an object keyed by document ID rather than an array (see FirebaseCollection):
+//
+//	{"versions": {"v12-prod": {"nodes": {<id>: {...}}, "nodeRevisions": {<id>: {...}}, "maps": {...},
+//	                           "nodeRatings": {<node id>: {<rating type>: {<user id>: {...}}}},
+//	                           "users": {<user id>: {...}}, "userExtras": {<user id>: {...}}}}}
+type FirebaseExport struct {
+	Versions map[string]json.RawMessage `json:"versions"`
+}
+
+type FirebaseVersionRoot struct {
+	Maps          FirebaseCollection `json:"maps"`
+	Nodes         FirebaseCollection `json:"nodes"`
+	NodeRevisions FirebaseCollection `json:"nodeRevisions"`
+	NodeRatings   FirebaseCollection `json:"nodeRatings"`
+	Users         FirebaseCollection `json:"users"`
+	UserExtras    FirebaseCollection `json:"userExtras"`
+}
+
+// A FirebaseCollection holds the documents of a collection by ID. Firebase exports a collection whose
+// IDs are all small numbers as an array instead of an object, with null for the missing IDs.
+type FirebaseCollection map[string]json.RawMessage
+
+func (c *FirebaseCollection) UnmarshalJSON(data []byte) error {
+	var docs map[string]json.RawMessage
+	if err := json.Unmarshal(data, &docs); err == nil {
+		*c = docs
+		return nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected an object or an array of documents")
+	}
+	*c = FirebaseCollection{}
+	for i, doc := range list {
+		if doc != nil && string(doc) != "null" {
+			(*c)[strconv.Itoa(i)] = doc
+		}
+	}
+	return nil
+}
+
+var firebaseVersionPattern = regexp.MustCompile(`^v(\d+)(-(.*))?$`)
+
+// parseFirebaseExport reads the chosen version root of a Firebase export into a DebateMapRoot.
+// If version is empty, the most recent version is used (see chooseFirebaseVersion).
+func parseFirebaseExport(data []byte, version string) (DebateMapRoot, error) {
+	root := DebateMapRoot{}
+	export := FirebaseExport{}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return root, fmt.Errorf("error parsing JSON: %s", err.Error())
+	}
+	if len(export.Versions) == 0 {
+		return root, fmt.Errorf("Firebase export contains no versions")
+	}
+
+	if version == "" {
+		version = chooseFirebaseVersion(export.Versions)
+		fmt.Fprintln(logOutput, "Using Firebase version root:", version)
+	}
+	rawVersion, ok := export.Versions[version]
+	if !ok {
+		return root, fmt.Errorf("Firebase export has no version %q (found: %s)", version, strings.Join(sortedRawKeys(export.Versions), ", "))
+	}
+
+	versionRoot := FirebaseVersionRoot{}
+	if err := json.Unmarshal(rawVersion, &versionRoot); err != nil {
+		return root, fmt.Errorf("error parsing version %s: %s", version, err.Error())
+	}
+
+	// The document IDs are the keys of each collection, so inject them as the _key
+	// of the documents, the way the other export formats provide them
+	for _, key := range sortedRawKeys(versionRoot.Maps) {
+		dmm := DebateMapMap{}
+		if err := json.Unmarshal(versionRoot.Maps[key], &dmm); err != nil {
+			return root, fmt.Errorf("error parsing map %s: %s", key, err.Error())
+		}
+		if dmm.ID == "" {
+			dmm.ID = key
+		}
+		root.Maps = append(root.Maps, dmm)
+	}
+	for _, key := range sortedRawKeys(versionRoot.NodeRevisions) {
+		rev := NodeRevision{}
+		if err := json.Unmarshal(versionRoot.NodeRevisions[key], &rev); err != nil {
+			return root, fmt.Errorf("error parsing node revision %s: %s", key, err.Error())
+		}
+		if rev.ID == "" {
+			rev.ID = key
+		}
+		root.NodeRevisions = append(root.NodeRevisions, rev)
+	}
+	for _, key := range sortedRawKeys(versionRoot.Nodes) {
+		node := DebateMapNode{}
+		if err := json.Unmarshal(versionRoot.Nodes[key], &node); err != nil {
+			return root, fmt.Errorf("error parsing node %s: %s", key, err.Error())
+		}
+		if node.ID == "" {
+			node.ID = key
+		}
+		if node.Current.ID == "" {
+			node.Current.ID = node.ID
+		}
+		root.Nodes = append(root.Nodes, node)
+	}
+	for _, key := range sortedRawKeys(versionRoot.NodeRatings) {
+		nr := NodeRatings{}
+		if err := json.Unmarshal(versionRoot.NodeRatings[key], &nr); err != nil {
+			return root, fmt.Errorf("error parsing the ratings of node %s: %s", key, err.Error())
+		}
+		if nr.ID == "" {
+			nr.ID = key
+		}
+		root.NodeRatings = append(root.NodeRatings, nr)
+	}
+	for _, key := range sortedRawKeys(versionRoot.Users) {
+		user := DebateMapUser{}
+		if err := json.Unmarshal(versionRoot.Users[key], &user); err != nil {
+			return root, fmt.Errorf("error parsing user %s: %s", key, err.Error())
+		}
+		if user.ID == "" {
+			user.ID = key
+		}
+		root.Users = append(root.Users, user)
+	}
+	for _, key := range sortedRawKeys(versionRoot.UserExtras) {
+		extras := DebateMapUserExtras{}
+		if err := json.Unmarshal(versionRoot.UserExtras[key], &extras); err != nil {
+			return root, fmt.Errorf("error parsing the extras of user %s: %s", key, err.Error())
+		}
+		if extras.ID == "" {
+			extras.ID = key
+		}
+		root.UserExtras = append(root.UserExtras, extras)
+	}
+
+	return root, nil
+}
+
+// chooseFirebaseVersion picks the version root with the highest number (e.g. "v12-prod" over "v11-prod"),
+// preferring the "-prod" root when several share the same number
+func chooseFirebaseVersion(versions map[string]json.RawMessage) string {
+	best := ""
+	bestNumber := -1
+	bestProd := false
+	for _, name := range sortedRawKeys(versions) {
+		number := -1
+		prod := false
+		if match := firebaseVersionPattern.FindStringSubmatch(name); match != nil {
+			number, _ = strconv.Atoi(match[1])
+			prod = match[3] == "prod"
+		}
+		if number > bestNumber || (number == bestNumber && prod && !bestProd) {
+			best = name
+			bestNumber = number
+			bestProd = prod
+		}
+	}
+	return best
+}
+
+func sortedRawKeys(m map[string]json.RawMessage) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// A Firebase export with two versions, the latest with its users as an array (their IDs are numbers)
+const testFirebase = `{"versions": {
+  "v11-prod": {"nodes": {"old": {"type": 40, "current": {"titles": {"base": "An old claim"}}}}},
+  "v12-test": {"nodes": {}},
+  "v12-prod": {
+    "nodes": {"R": {"type": 40, "creator": "1", "current": {"titles": {"base": "Nuclear power should replace coal"}}}},
+    "nodeRatings": {"R": {"probability": {"_key": "probability", "2": {"updated": 1552333000000, "value": 80}}}},
+    "users": [null, {"displayName": "Ana"}, {"displayName": "Bruno"}]
+  }
+}}`
+
+func TestChooseFirebaseVersion(t *testing.T) {
+	tests := []struct {
+		Versions []string
+		Expected string
+	}{
+		{[]string{"v11-prod", "v12-prod", "v9-prod"}, "v12-prod"},
+		{[]string{"v12-test", "v12-prod", "v12-dev"}, "v12-prod"},
+		{[]string{"v2", "v10"}, "v10"},
+		{[]string{"backup", "v1-test"}, "v1-test"},
+	}
+	for _, test := range tests {
+		versions := map[string]json.RawMessage{}
+		for _, version := range test.Versions {
+			versions[version] = json.RawMessage("{}")
+		}
+		if version := chooseFirebaseVersion(versions); version != test.Expected {
+			t.Errorf("chooseFirebaseVersion(%v) = %s, expected %s", test.Versions, version, test.Expected)
+		}
+	}
+}
+
+func TestParseFirebaseExport(t *testing.T) {
+	// Without --fb-version, the latest production root
+	root, err := parseFirebaseExport([]byte(testFirebase), "")
+	if err != nil {
+		t.Fatalf("parseFirebaseExport: %s", err.Error())
+	}
+	if len(root.Nodes) != 1 || root.Nodes[0].ID != "R" || root.Nodes[0].Current.ID != "R" {
+		t.Errorf("read the nodes %+v, expected R", root.Nodes)
+	}
+	if len(root.NodeRatings) != 1 || root.NodeRatings[0].ID != "R" {
+		t.Errorf("read the ratings %+v, expected those of R", root.NodeRatings)
+	}
+	// The users array is read by index, without the missing user 0
+	if len(root.Users) != 2 || root.Users[0].ID != "1" || root.Users[1].ID != "2" || root.Users[1].DisplayName != "Bruno" {
+		t.Errorf("read the users %+v, expected 1 and 2", root.Users)
+	}
+
+	root, err = parseFirebaseExport([]byte(testFirebase), "v11-prod")
+	if err != nil {
+		t.Fatalf("parseFirebaseExport(v11-prod): %s", err.Error())
+	}
+	if len(root.Nodes) != 1 || root.Nodes[0].ID != "old" {
+		t.Errorf("read the nodes %+v of v11-prod, expected old", root.Nodes)
+	}
+
+	_, err = parseFirebaseExport([]byte(testFirebase), "v13-prod")
+	if err == nil || !strings.Contains(err.Error(), `no version "v13-prod" (found: v11-prod, v12-prod, v12-test)`) {
+		t.Errorf("parseFirebaseExport(v13-prod) returned the error %v", err)
+	}
+	if _, err := parseFirebaseExport([]byte(`{"versions": {}}`), ""); err == nil {
+		t.Errorf("parseFirebaseExport read an export without versions")
+	}
+	if _, err := parseFirebaseExport([]byte(`{"versions": {"v1": {"nodes": "R"}}}`), ""); err == nil {
+		t.Errorf("parseFirebaseExport read a collection that is neither an object nor an array")
+	}
+}
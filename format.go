@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+const FORMAT_UNKNOWN int = 0
+const FORMAT_NODES int = 1
+const FORMAT_GENERAL int = 2
+const FORMAT_FIREBASE int = 3
+const FORMAT_AIF int = 4
+const FORMAT_ARGDOWN int = 5
+const FORMAT_KIALO int = 6
+const FORMAT_CSV int = 7
+
+// FORMAT_AUTO_NAME is only used as the value of the --format flag,
+// meaning that the format should be detected from the data itself
+const FORMAT_AUTO_NAME = "auto"
+
+var formatNames = map[int]string{
+	FORMAT_NODES:    "nodes",
+	FORMAT_GENERAL:  "general",
+	FORMAT_FIREBASE: "firebase",
+	FORMAT_AIF:      "aif",
+	FORMAT_ARGDOWN:  "argdown",
+	FORMAT_KIALO:    "kialo",
+	FORMAT_CSV:      "csv",
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func formatName(format int) string {
+	if name, ok := formatNames[format]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// parseFormatName converts the value of the --format flag into one of the FORMAT_ constants.
+// FORMAT_UNKNOWN is returned for "auto", meaning the format should be detected.
+func parseFormatName(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == FORMAT_AUTO_NAME {
+		return FORMAT_UNKNOWN, nil
+	}
+	for format, n := range formatNames {
+		if n == name {
+			return format, nil
+		}
+	}
+	return FORMAT_UNKNOWN, fmt.Errorf("unknown format %q (expected one of: %s)", name, strings.Join(formatNameList(), ", "))
+}
+
+func formatNameList() []string {
+	formats := []int{}
+	for format := range formatNames {
+		formats = append(formats, format)
+	}
+	sort.Ints(formats)
+	names := []string{FORMAT_AUTO_NAME}
+	for _, format := range formats {
+		names = append(names, formatNames[format])
+	}
+	return names
+}
+
+// trimInput removes a leading UTF-8 byte order mark and surrounding whitespace,
+// neither of which the JSON decoder accepts before the first token
+func trimInput(data []byte) []byte {
+	return bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+}
+
+// detectInputFormat recognizes text formats by the file extension or, failing that,
+// by their first lines, before trying the JSON formats with detectFormat
+func detectInputFormat(input InputFile) (int, error) {
+	switch strings.ToLower(path.Ext(input.Name)) {
+	case ".argdown", ".ad":
+		return FORMAT_ARGDOWN, nil
+	case ".csv":
+		return FORMAT_CSV, nil
+	}
+	format, err := detectFormat(input.Data)
+	if err != nil {
+		if looksLikeCSV(input.Data) {
+			return FORMAT_CSV, nil
+		}
+		if looksLikeKialo(input.Data) {
+			return FORMAT_KIALO, nil
+		}
+		if looksLikeArgdown(input.Data) {
+			return FORMAT_ARGDOWN, nil
+		}
+	}
+	return format, err
+}
+
+// detectFormat inspects the structure of the JSON document (rather than its first bytes),
+// so that pretty-printed files and nodes with their keys in any order are recognized.
+//   - An array whose first element looks like a Debate Map node is in the NODES format
+//   - An object with a "general" key, or with "nodes" next to "maps", "nodeRevisions", "nodeRatings" or "users",
+//     is in the GENERAL format
+//   - An object with a "versions" key is a raw Firebase Realtime Database export (FIREBASE format)
+//   - An object with "nodes" and "edges" keys is an Argument Interchange Format document (AIF format)
+func detectFormat(data []byte) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(trimInput(data)))
+	tok, err := dec.Token()
+	if err != nil {
+		return FORMAT_UNKNOWN, fmt.Errorf("data is not valid JSON: %s", err.Error())
+	}
+
+	switch tok {
+	case json.Delim('['):
+		if !dec.More() {
+			// An empty list of nodes is still a list of nodes
+			return FORMAT_NODES, nil
+		}
+		tok, err = dec.Token()
+		if err != nil {
+			return FORMAT_UNKNOWN, fmt.Errorf("data is not valid JSON: %s", err.Error())
+		}
+		if tok != json.Delim('{') {
+			return FORMAT_UNKNOWN, fmt.Errorf("data is a JSON array, but its first element is not an object")
+		}
+		keys, err := readObjectKeys(dec)
+		if err != nil {
+			return FORMAT_UNKNOWN, err
+		}
+		if keys["type"] || keys["current"] || keys["currentRevision"] {
+			return FORMAT_NODES, nil
+		}
+		return FORMAT_UNKNOWN, fmt.Errorf("data is a JSON array, but its first element is not a Debate Map node (keys: %s)", joinKeys(keys))
+	case json.Delim('{'):
+		keys, err := readObjectKeys(dec)
+		if err != nil {
+			return FORMAT_UNKNOWN, err
+		}
+		if keys["versions"] {
+			return FORMAT_FIREBASE, nil
+		}
+		if keys["nodes"] && keys["edges"] {
+			return FORMAT_AIF, nil
+		}
+		if keys["general"] || (keys["nodes"] && (keys["maps"] || keys["nodeRevisions"] || keys["nodeRatings"] || keys["users"])) {
+			return FORMAT_GENERAL, nil
+		}
+		return FORMAT_UNKNOWN, fmt.Errorf("data is a JSON object with unrecognized top-level keys: %s", joinKeys(keys))
+	default:
+		return FORMAT_UNKNOWN, fmt.Errorf("data must be a JSON array or object, found %v", tok)
+	}
+}
+
+// readObjectKeys reads the remainder of an object whose opening brace has already been consumed,
+// returning its keys and skipping over their values
+func readObjectKeys(dec *json.Decoder) (map[string]bool, error) {
+	keys := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return keys, fmt.Errorf("data is not valid JSON: %s", err.Error())
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return keys, fmt.Errorf("data is not valid JSON: expected an object key, found %v", tok)
+		}
+		keys[key] = true
+		if err = skipValue(dec); err != nil {
+			return keys, err
+		}
+	}
+	if _, err := dec.Token(); err != nil && err != io.EOF {
+		return keys, fmt.Errorf("data is not valid JSON: %s", err.Error())
+	}
+	return keys, nil
+}
+
+// skipValue consumes the next value from the decoder, including any nested arrays and objects
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("data is not valid JSON: %s", err.Error())
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func joinKeys(keys map[string]bool) string {
+	list := []string{}
+	for key := range keys {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	if len(list) > 10 {
+		list = append(list[:10], "...")
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectInputFormat(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Data   string
+		Format int
+	}{
+		// Pretty-printed, with a byte order mark, and with the keys of the node in any order
+		{"nodes.json", "\xEF\xBB\xBF\n[\n  {\"creator\": \"u1\", \"type\": 40}\n]", FORMAT_NODES},
+		{"nodes.json", "[]", FORMAT_NODES},
+		{"backup.json", `{"nodes": [], "maps": []}`, FORMAT_GENERAL},
+		{"backup.json", `{"nodeRatings": [], "nodes": []}`, FORMAT_GENERAL},
+		{"backup.json", `{"general": {"nodes": []}}`, FORMAT_GENERAL},
+		{"firebase.json", `{"versions": {"v12-prod": {}}}`, FORMAT_FIREBASE},
+		{"map.json", `{"edges": [], "nodes": []}`, FORMAT_AIF},
+		{"debate.argdown", "[A]: Anything.", FORMAT_ARGDOWN},
+		{"debate.txt", "[A]: Nuclear is clean.\n  + [B]: Nuclear emits little CO2.", FORMAT_ARGDOWN},
+		{"debate.txt", "Discussion Title: Nuclear\n\n1. Nuclear is clean.", FORMAT_KIALO},
+		{"sheet.csv", "anything", FORMAT_CSV},
+		{"sheet.txt", "id,kind,title\nt,claim,Nuclear is clean.", FORMAT_CSV},
+	}
+	for _, test := range tests {
+		format, err := detectInputFormat(InputFile{Name: test.Name, Data: []byte(test.Data)})
+		if err != nil || format != test.Format {
+			t.Errorf("detectInputFormat(%s, %q) = %s, %v, expected %s", test.Name, test.Data, formatName(format), err, formatName(test.Format))
+		}
+	}
+}
+
+func TestDetectFormatErrors(t *testing.T) {
+	tests := []struct {
+		Data  string
+		Error string
+	}{
+		{`[{"name": "x"}]`, "not a Debate Map node (keys: name)"},
+		{`[1, 2]`, "first element is not an object"},
+		{`{"claims": []}`, "unrecognized top-level keys: claims"},
+		{`"nodes"`, "must be a JSON array or object"},
+		{`{"nodes": [`, "not valid JSON"},
+	}
+	for _, test := range tests {
+		format, err := detectFormat([]byte(test.Data))
+		if format != FORMAT_UNKNOWN || err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("detectFormat(%q) = %s, %v, expected an error with %q", test.Data, formatName(format), err, test.Error)
+		}
+	}
+}
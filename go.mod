@@ -1,8 +1,16 @@
 module github.com/canonical-debate-lab/arango-importer
 
-go 1.12
+go 1.22
 
 require (
+	github.com/abadojack/whatlanggo v1.0.1
 	github.com/arangodb/go-driver v0.0.0-20190408134854-544fae7debeb
 	github.com/google/uuid v1.1.1
+	github.com/klauspost/compress v1.18.0
+	github.com/lib/pq v1.9.0
+	github.com/mattn/go-sqlite3 v1.14.22
+	golang.org/x/text v0.22.0
+	gopkg.in/yaml.v2 v2.4.0
 )
+
+require github.com/arangodb/go-velocypack v0.0.0-20180928134037-d177e3455691 // indirect
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// Graph holds the vertices and edges of a Canonical Debate graph in memory
+type Graph struct {
+	Claims     []Claim
+	Arguments  []Argument
+	Inferences []Inference
+	BaseClaims []BaseClaim
+	Premises   []Premise
+	// The ratings of the Debate Map users, and the edges to what they rated
+	Ratings       []Rating
+	RatingTargets []RatingTarget
+	// The authors of the claims, arguments and ratings, and the edges to what they wrote
+	Users     []User
+	Creations []Creation
+}
+
+// ClaimsByArangoID indexes the Claims by their document handle (e.g. "claims/<key>"),
+// which is what the edges point to
+func (g *Graph) ClaimsByArangoID() map[string]Claim {
+	m := map[string]Claim{}
+	for _, claim := range g.Claims {
+		m[claim.ArangoID()] = claim
+	}
+	return m
+}
+
+// ArgumentsByArangoID indexes the Arguments by their document handle (e.g. "arguments/<key>")
+func (g *Graph) ArgumentsByArangoID() map[string]Argument {
+	m := map[string]Argument{}
+	for _, arg := range g.Arguments {
+		m[arg.ArangoID()] = arg
+	}
+	return m
+}
+
+// Documents lists the documents of each collection, by collection name
+func (g *Graph) Documents() map[string][]interface{} {
+	docs := map[string][]interface{}{}
+	for _, claim := range g.Claims {
+		docs["claims"] = append(docs["claims"], claim)
+	}
+	for _, arg := range g.Arguments {
+		docs["arguments"] = append(docs["arguments"], arg)
+	}
+	for _, inference := range g.Inferences {
+		docs["inferences"] = append(docs["inferences"], inference)
+	}
+	for _, bc := range g.BaseClaims {
+		docs["base_claims"] = append(docs["base_claims"], bc)
+	}
+	for _, premise := range g.Premises {
+		docs["premises"] = append(docs["premises"], premise)
+	}
+	for _, rating := range g.Ratings {
+		docs["ratings"] = append(docs["ratings"], rating)
+	}
+	for _, edge := range g.RatingTargets {
+		docs["rating_targets"] = append(docs["rating_targets"], edge)
+	}
+	for _, user := range g.Users {
+		docs["users"] = append(docs["users"], user)
+	}
+	for _, creation := range g.Creations {
+		docs["created"] = append(docs["created"], creation)
+	}
+	return docs
+}
+
+// DebateRoots returns the root of the debate each Claim and Argument belongs to, by document handle.
+// The edges point from the parent to the child, so the root is found by following the first parent of each
+// document up; a document without parents is its own root.
+func (g *Graph) DebateRoots() map[string]string {
+	parents := map[string]string{}
+	addParent := func(from, to string) {
+		if _, ok := parents[to]; !ok {
+			parents[to] = from
+		}
+	}
+	for _, inference := range g.Inferences {
+		addParent(inference.From, inference.To)
+	}
+	for _, bc := range g.BaseClaims {
+		addParent(bc.From, bc.To)
+	}
+	for _, premise := range g.Premises {
+		addParent(premise.From, premise.To)
+	}
+
+	roots := map[string]string{}
+	findRoot := func(handle string) string {
+		seen := map[string]bool{}
+		for {
+			if root, ok := roots[handle]; ok {
+				return root
+			}
+			parent, ok := parents[handle]
+			if !ok || seen[parent] {
+				return handle
+			}
+			seen[handle] = true
+			handle = parent
+		}
+	}
+	for _, claim := range g.Claims {
+		roots[claim.ArangoID()] = findRoot(claim.ArangoID())
+	}
+	for _, arg := range g.Arguments {
+		roots[arg.ArangoID()] = findRoot(arg.ArangoID())
+	}
+	return roots
+}
+
+// PremisesByClaim returns the Premise edges of each MP Claim (by document handle), sorted by Order
+func (g *Graph) PremisesByClaim() map[string][]Premise {
+	m := map[string][]Premise{}
+	for _, premise := range g.Premises {
+		m[premise.From] = append(m[premise.From], premise)
+	}
+	for _, premises := range m {
+		sort.SliceStable(premises, func(i, j int) bool { return premises[i].Order < premises[j].Order })
+	}
+	return m
+}
+
+// A collectionReader calls add with the JSON of each document of the named collection.
+// Collections that are not required may be missing from the source.
+type collectionReader func(name string, required bool, add func([]byte) error) error
+
+func loadGraph(read collectionReader) (*Graph, error) {
+	g := &Graph{}
+	if err := read("claims", true, func(doc []byte) error {
+		claim := Claim{}
+		err := json.Unmarshal(doc, &claim)
+		g.Claims = append(g.Claims, claim)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("arguments", true, func(doc []byte) error {
+		arg := Argument{}
+		err := json.Unmarshal(doc, &arg)
+		g.Arguments = append(g.Arguments, arg)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("inferences", false, func(doc []byte) error {
+		inference := Inference{}
+		err := json.Unmarshal(doc, &inference)
+		g.Inferences = append(g.Inferences, inference)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("base_claims", false, func(doc []byte) error {
+		bc := BaseClaim{}
+		err := json.Unmarshal(doc, &bc)
+		g.BaseClaims = append(g.BaseClaims, bc)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("premises", false, func(doc []byte) error {
+		premise := Premise{}
+		err := json.Unmarshal(doc, &premise)
+		g.Premises = append(g.Premises, premise)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("ratings", false, func(doc []byte) error {
+		rating := Rating{}
+		err := json.Unmarshal(doc, &rating)
+		g.Ratings = append(g.Ratings, rating)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("rating_targets", false, func(doc []byte) error {
+		edge := RatingTarget{}
+		err := json.Unmarshal(doc, &edge)
+		g.RatingTargets = append(g.RatingTargets, edge)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("users", false, func(doc []byte) error {
+		user := User{}
+		err := json.Unmarshal(doc, &user)
+		g.Users = append(g.Users, user)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := read("created", false, func(doc []byte) error {
+		creation := Creation{}
+		err := json.Unmarshal(doc, &creation)
+		g.Creations = append(g.Creations, creation)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(logOutput, "Read %d claims, %d arguments, %d inferences, %d base claims, %d premises, %d ratings and %d users\n",
+		len(g.Claims), len(g.Arguments), len(g.Inferences), len(g.BaseClaims), len(g.Premises), len(g.Ratings), len(g.Users))
+	return g, nil
+}
+
+// loadGraphFromDB reads every vertex and edge collection of the graph from the database.
+// Missing edge collections, and the ratings and users of a database created before them, are treated as empty.
+func loadGraphFromDB(db driver.Database) (*Graph, error) {
+	return loadGraph(func(name string, required bool, add func([]byte) error) error {
+		exists, err := db.CollectionExists(nil, name)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %s", name, err.Error())
+		}
+		if !exists {
+			if required {
+				return fmt.Errorf("the database has no %s collection", name)
+			}
+			fmt.Fprintln(logOutput, "No collection:", name)
+			return nil
+		}
+		fmt.Fprintln(logOutput, "Reading collection:", name)
+		cursor, err := db.Query(nil, "FOR d IN @@col SORT d._key RETURN d", map[string]interface{}{"@col": name})
+		if err != nil {
+			return fmt.Errorf("error querying %s: %s", name, err.Error())
+		}
+		defer cursor.Close()
+		for cursor.HasMore() {
+			doc := json.RawMessage{}
+			if _, err := cursor.ReadDocument(nil, &doc); err != nil {
+				return fmt.Errorf("error reading %s: %s", name, err.Error())
+			}
+			if err := add(doc); err != nil {
+				return fmt.Errorf("error reading %s: %s", name, err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+// loadGraphFromDump reads the graph from a directory of JSONL files, one per collection,
+// such as the ones written by "arangoexport --type jsonl" (claims.jsonl, arguments.jsonl, ...).
+// Missing edge collections are treated as empty.
+func loadGraphFromDump(dir string) (*Graph, error) {
+	return loadGraph(func(name string, required bool, add func([]byte) error) error {
+		return readJSONL(dir, name, required, add)
+	})
+}
+
+func readJSONL(dir, name string, required bool, add func([]byte) error) error {
+	filename := filepath.Join(dir, name+".jsonl")
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) && !required {
+		fmt.Fprintln(logOutput, "No dump file for collection:", name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fmt.Fprintln(logOutput, "Reading dump file:", filename)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := trimInput(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := add(line); err != nil {
+			return fmt.Errorf("%s:%d: %s", filename, lineNumber, err.Error())
+		}
+	}
+	return scanner.Err()
+}
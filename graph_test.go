@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// A small Debate Map export: a claim supported by a multi-premise argument and attacked by a single claim,
+// with the ratings and users of the Firebase backups
+const testExport = `{
+  "nodes": [
+    {"_key": "R", "type": 40, "createdAt": 1552331608312, "creator": "u1",
+     "parents": {"_key": "parents"},
+     "children": {"_key": "children", "A": {"_": true, "_key": "A", "polarity": 10}, "C": {"_": true, "_key": "C", "polarity": 20}},
+     "current": {"titles": {"base": "Nuclear power should replace the coal power plants in every country."}}},
+    {"_key": "A", "type": 50, "createdAt": 1552331700000, "creator": "u2", "multiPremiseArgument": true,
+     "parents": {"_key": "parents", "R": {"_": true, "_key": "R"}},
+     "children": {"_key": "children", "P1": {"_": true, "_key": "P1"}, "P2": {"_": true, "_key": "P2"}},
+     "childrenOrder": ["P1", "P2"],
+     "current": {"argumentType": 20, "titles": {"base": "Nuclear power is cleaner and cheaper than coal power in the long run."}}},
+    {"_key": "P1", "type": 40, "createdAt": 1552331800000, "creator": "u2",
+     "parents": {"_key": "parents", "A": {"_": true, "_key": "A"}},
+     "current": {"titles": {"base": "Nuclear power plants emit far less carbon dioxide than coal power plants."}}},
+    {"_key": "P2", "type": 40, "createdAt": 1552331900000, "creator": "u2",
+     "parents": {"_key": "parents", "A": {"_": true, "_key": "A"}},
+     "current": {"titles": {"base": "The \"levelized\" cost of nuclear power keeps falling every single year."}}},
+    {"_key": "C", "type": 50, "createdAt": 1552332000000, "creator": "u1",
+     "parents": {"_key": "parents", "R": {"_": true, "_key": "R"}},
+     "children": {"_key": "children", "X": {"_": true, "_key": "X"}},
+     "childrenOrder": ["X"],
+     "current": {"argumentType": 20, "titles": {"base": ""}}},
+    {"_key": "X", "type": 40, "createdAt": 1552332100000, "creator": "u3",
+     "parents": {"_key": "parents", "C": {"_": true, "_key": "C"}},
+     "current": {"titles": {"base": "The waste of nuclear power plants stays dangerous for thousands of years."}}}
+  ],
+  "nodeRatings": [
+    {"_key": "R", "probability": {"_key": "probability", "u2": {"updated": 1552333000000, "value": 80}, "u3": {"updated": 1552333100000, "value": 40}}},
+    {"_key": "A", "relevance": {"_key": "relevance", "u1": {"updated": 1552333200000, "value": 90}}}
+  ],
+  "users": [{"_key": "u1", "displayName": "Ana"}, {"_key": "u2", "displayName": "Bruno"}],
+  "userExtras": [{"_key": "u1", "joinDate": 1552330000000}]
+}`
+
+// testGraph converts the test export, as the import does before scoring it
+func testGraph(t *testing.T) *Graph {
+	t.Helper()
+	root := DebateMapRoot{}
+	if err := json.Unmarshal([]byte(testExport), &root); err != nil {
+		t.Fatalf("reading the test export: %s", err.Error())
+	}
+	g := buildGraph(root)
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+	return g
+}
+
+func TestTestGraph(t *testing.T) {
+	g := testGraph(t)
+	// R, P1, P2 and X, and the multi-premise claim of A
+	if len(g.Claims) != 5 || len(g.Arguments) != 2 || len(g.Premises) != 2 {
+		t.Fatalf("built %d claims, %d arguments and %d premises, expected 5, 2 and 2", len(g.Claims), len(g.Arguments), len(g.Premises))
+	}
+	if len(g.Ratings) != 3 || len(g.RatingTargets) != 3 {
+		t.Fatalf("built %d ratings and %d rating targets, expected 3 and 3", len(g.Ratings), len(g.RatingTargets))
+	}
+	// Ana and Bruno, and u3 who only wrote
+	if len(g.Users) != 3 {
+		t.Fatalf("built %d users, expected 3", len(g.Users))
+	}
+}
+
+// A database created before the ratings and users has only the collections of the debate
+func TestLoadGraphFromDBWithoutRatingsAndUsers(t *testing.T) {
+	g := testGraph(t)
+	db := collectionsDB{collections: map[string][]string{}}
+	for name, docs := range g.Documents() {
+		if name == "ratings" || name == "rating_targets" || name == "users" || name == "created" {
+			continue
+		}
+		for _, doc := range docs {
+			data, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("writing %s: %s", name, err.Error())
+			}
+			db.collections[name] = append(db.collections[name], string(data))
+		}
+	}
+
+	loaded, err := loadGraphFromDB(db)
+	if err != nil {
+		t.Fatalf("loadGraphFromDB: %s", err.Error())
+	}
+	if len(loaded.Claims) != len(g.Claims) || len(loaded.Premises) != len(g.Premises) || len(loaded.Ratings) != 0 || len(loaded.Users) != 0 {
+		t.Errorf("read %d claims, %d premises, %d ratings and %d users, expected %d, %d, 0 and 0",
+			len(loaded.Claims), len(loaded.Premises), len(loaded.Ratings), len(loaded.Users), len(g.Claims), len(g.Premises))
+	}
+
+	delete(db.collections, "claims")
+	if _, err := loadGraphFromDB(db); err == nil {
+		t.Errorf("loadGraphFromDB read a database without claims")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// A GraphIndex is a persistent index on the fields of a collection
+type GraphIndex struct {
+	Collection string
+	Fields     []string
+	Unique     bool
+	// Sparse indexes leave out the documents where the field is null or missing
+	Sparse bool
+}
+
+// The indexes of the graph, for the lookups of server-api. They are created by the arango sink and schema apply,
+// and are part of the schema generated for the SQL sinks.
+var graphIndexes = []GraphIndex{
+	{Collection: "claims", Fields: []string{"id"}, Unique: true},
+	{Collection: "arguments", Fields: []string{"id"}, Unique: true},
+	{Collection: "arguments", Fields: []string{"targetClaimId"}, Sparse: true},
+	{Collection: "arguments", Fields: []string{"targetArgId"}, Sparse: true},
+	{Collection: "arguments", Fields: []string{"claimId"}},
+	{Collection: "premises", Fields: []string{"order"}},
+	{Collection: "ratings", Fields: []string{"node", "type"}},
+	{Collection: "users", Fields: []string{"id"}, Unique: true},
+	{Collection: "users", Fields: []string{"account"}, Sparse: true},
+}
+
+// Name identifies the index in the reports and SQL schema, e.g. "arguments_targetClaimId"
+func (idx GraphIndex) Name() string {
+	return idx.Collection + "_" + strings.Join(idx.Fields, "_")
+}
+
+func (idx GraphIndex) String() string {
+	kind := "index"
+	if idx.Unique {
+		kind = "unique index"
+	} else if idx.Sparse {
+		kind = "sparse index"
+	}
+	return fmt.Sprintf("%s on %s(%s)", kind, idx.Collection, strings.Join(idx.Fields, ", "))
+}
+
+// ensureIndexes creates the missing indexes, and reports each of them
+func ensureIndexes(db driver.Database) ([]string, error) {
+	report := []string{}
+	for _, idx := range graphIndexes {
+		col, err := db.Collection(nil, idx.Collection)
+		if err != nil {
+			return report, err
+		}
+		_, created, err := col.EnsurePersistentIndex(nil, idx.Fields, &driver.EnsurePersistentIndexOptions{Unique: idx.Unique, Sparse: idx.Sparse})
+		if err != nil {
+			return report, fmt.Errorf("creating the %s: %s", idx, err.Error())
+		}
+		if created {
+			report = append(report, fmt.Sprintf("created %s", idx))
+		} else {
+			report = append(report, fmt.Sprintf("%s already exists", idx))
+		}
+	}
+	return report, nil
+}
+
+// checkUniqueIndexes finds the documents that a unique index would reject, before anything is written
+func checkUniqueIndexes(g *Graph) error {
+	docs := g.Documents()
+	problems := []string{}
+	for _, idx := range graphIndexes {
+		if !idx.Unique {
+			continue
+		}
+		seen := map[string]int{}
+		for _, doc := range docs[idx.Collection] {
+			data, err := json.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			fields := map[string]interface{}{}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				return err
+			}
+			values := []string{}
+			for _, field := range idx.Fields {
+				values = append(values, fmt.Sprintf("%v", fields[field]))
+			}
+			seen[strings.Join(values, ", ")]++
+		}
+		duplicates := []string{}
+		for value, count := range seen {
+			if count > 1 {
+				duplicates = append(duplicates, fmt.Sprintf("%s: %d documents with %s %q", idx.Collection, count, strings.Join(idx.Fields, ", "), value))
+			}
+		}
+		sort.Strings(duplicates)
+		problems = append(problems, duplicates...)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d values are not unique:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -13,3 +13,13 @@ type Inference struct {
 	From      string    `json:"_from,omitempty"`
 	To        string    `json:"_to,omitempty"`
 }
+
+func NewInference(fromid string, toArg Argument) Inference {
+	return Inference{
+		Key:       edgeKey("inferences", fromid, toArg.ArangoID()),
+		CreatedAt: toArg.CreatedAt,
+		Creator:   toArg.Creator,
+		From:      fromid,
+		To:        toArg.ArangoID(),
+	}
+}
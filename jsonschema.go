@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// A JSONSchema is the subset of JSON Schema used for the collection rules of ArangoDB,
+// which the importer also checks its documents against before writing them
+type JSONSchema struct {
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+}
+
+const SCHEMA_LEVEL = "strict"
+
+// The collections with a schema rule, and the type of their documents
+var schemaCollections = []struct {
+	Name string
+	Type reflect.Type
+}{
+	{"claims", reflect.TypeOf(Claim{})},
+	{"arguments", reflect.TypeOf(Argument{})},
+	{"inferences", reflect.TypeOf(Inference{})},
+	{"base_claims", reflect.TypeOf(BaseClaim{})},
+	{"premises", reflect.TypeOf(Premise{})},
+	{"ratings", reflect.TypeOf(Rating{})},
+	{"rating_targets", reflect.TypeOf(RatingTarget{})},
+	{"users", reflect.TypeOf(User{})},
+	{"created", reflect.TypeOf(Creation{})},
+}
+
+func schemaInt(i int) *int           { return &i }
+func schemaFloat(f float64) *float64 { return &f }
+func schemaBool(b bool) *bool        { return &b }
+func schemaRange(min, max float64) *JSONSchema {
+	return &JSONSchema{Minimum: schemaFloat(min), Maximum: schemaFloat(max)}
+}
+
+// Constraints that the Go types don't tell, by JSON field name
+var schemaConstraints = map[string]*JSONSchema{
+	"id":        {MinLength: schemaInt(1)},
+	"truth":     schemaRange(0, 1),
+	"relevance": schemaRange(0, 1),
+	"strength":  schemaRange(0, 1),
+	"mprule":    schemaRange(float64(PREMISE_RULE_NONE), float64(PREMISE_RULE_ANY_TWO)),
+	"order":     {Minimum: schemaFloat(0)},
+	"value":     schemaRange(0, 1),
+}
+
+// The fields a document must have: those identifying it, and its scores.
+// The others (the texts, lang, creator...) may be left out by documents other tools write.
+var schemaRequiredFields = map[string]bool{
+	"id":        true,
+	"node":      true,
+	"type":      true,
+	"order":     true,
+	"truth":     true,
+	"relevance": true,
+	"strength":  true,
+	"value":     true,
+}
+
+// documentSchema generates the rule for a document type from its JSON fields.
+// The identity and score fields are required unless they have omitempty, pointers may be null,
+// and other attributes are allowed.
+// The system attributes (_key, _from, _to) are left out, as ArangoDB doesn't validate them.
+func documentSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: schemaBool(true),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "" || name == "-" || strings.HasPrefix(name, "_") {
+			continue
+		}
+		fieldType := field.Type
+		nullable := false
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			nullable = true
+		}
+		property := &JSONSchema{}
+		switch {
+		case fieldType == reflect.TypeOf(time.Time{}):
+			property.Type = "string"
+			property.Format = "date-time"
+		case fieldType.Kind() == reflect.String:
+			property.Type = "string"
+		case fieldType.Kind() == reflect.Bool:
+			property.Type = "boolean"
+		case fieldType.Kind() == reflect.Int:
+			property.Type = "integer"
+		case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+			property.Type = "number"
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+			property.Type = "array"
+			property.Items = &JSONSchema{Type: "string"}
+		default:
+			panic(fmt.Sprintf("No JSON schema type for %s.%s (%s)", t.Name(), field.Name, fieldType))
+		}
+		if nullable {
+			property.Type = []string{property.Type.(string), "null"}
+		}
+		if constraint, ok := schemaConstraints[name]; ok {
+			property.MinLength = constraint.MinLength
+			property.Minimum = constraint.Minimum
+			property.Maximum = constraint.Maximum
+		}
+		schema.Properties[name] = property
+
+		omitempty := len(tag) > 1 && tag[1] == "omitempty"
+		if schemaRequiredFields[name] && !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// validateDocument checks a document against the schema, and returns the violations
+func validateDocument(schema *JSONSchema, doc interface{}) ([]string, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return validateValue(schema, value, ""), nil
+}
+
+func validateValue(schema *JSONSchema, value interface{}, at string) []string {
+	problems := []string{}
+	fail := func(format string, args ...interface{}) {
+		name := at
+		if name == "" {
+			name = "document"
+		}
+		problems = append(problems, name+": "+fmt.Sprintf(format, args...))
+	}
+
+	types := []string{}
+	switch t := schema.Type.(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	}
+	if len(types) > 0 {
+		found := jsonType(value)
+		ok := false
+		for _, t := range types {
+			ok = ok || t == found || (t == "number" && found == "integer")
+		}
+		if !ok {
+			fail("expected %s, found %s", strings.Join(types, " or "), found)
+			return problems
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required field %q", name)
+			}
+		}
+		names := []string{}
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if fieldValue, ok := v[name]; ok {
+				problems = append(problems, validateValue(schema.Properties[name], fieldValue, name)...)
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range v {
+				problems = append(problems, validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	case string:
+		if schema.MinLength != nil && len(v) < *schema.MinLength {
+			fail("must have at least %d characters", *schema.MinLength)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				fail("expected a date-time, found %q", v)
+			}
+		}
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			fail("%v is less than the minimum %v", v, *schema.Minimum)
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			fail("%v is more than the maximum %v", v, *schema.Maximum)
+		}
+	}
+	return problems
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// validateGraph checks every document against the schema of its collection, before anything is written.
+// Each violation names the Debate Map node of the document (or the ends of an edge).
+func validateGraph(g *Graph) error {
+	schemas := map[string]*JSONSchema{}
+	for _, col := range schemaCollections {
+		schemas[col.Name] = documentSchema(col.Type)
+	}
+
+	problems := []string{}
+	check := func(collection, node string, doc interface{}) error {
+		violations, err := validateDocument(schemas[collection], doc)
+		if err != nil {
+			return err
+		}
+		for _, violation := range violations {
+			problems = append(problems, fmt.Sprintf("%s %s: %s", collection, node, violation))
+		}
+		return nil
+	}
+	for _, claim := range g.Claims {
+		if err := check("claims", fmt.Sprintf("node %q (_key %s)", claim.ID, claim.Key), claim); err != nil {
+			return err
+		}
+	}
+	for _, arg := range g.Arguments {
+		if err := check("arguments", fmt.Sprintf("node %q (_key %s)", arg.ID, arg.Key), arg); err != nil {
+			return err
+		}
+	}
+	for _, inference := range g.Inferences {
+		if err := check("inferences", fmt.Sprintf("%s -> %s (_key %s)", inference.From, inference.To, inference.Key), inference); err != nil {
+			return err
+		}
+	}
+	for _, bc := range g.BaseClaims {
+		if err := check("base_claims", fmt.Sprintf("%s -> %s (_key %s)", bc.From, bc.To, bc.Key), bc); err != nil {
+			return err
+		}
+	}
+	for _, premise := range g.Premises {
+		if err := check("premises", fmt.Sprintf("%s -> %s (_key %s)", premise.From, premise.To, premise.Key), premise); err != nil {
+			return err
+		}
+	}
+	for _, rating := range g.Ratings {
+		if err := check("ratings", fmt.Sprintf("%s rating of node %q by %s (_key %s)", rating.Type, rating.Node, rating.Creator, rating.Key), rating); err != nil {
+			return err
+		}
+	}
+	for _, edge := range g.RatingTargets {
+		if err := check("rating_targets", fmt.Sprintf("%s -> %s (_key %s)", edge.From, edge.To, edge.Key), edge); err != nil {
+			return err
+		}
+	}
+	for _, user := range g.Users {
+		if err := check("users", fmt.Sprintf("%q (_key %s)", user.ID, user.Key), user); err != nil {
+			return err
+		}
+	}
+	for _, creation := range g.Creations {
+		if err := check("created", fmt.Sprintf("%s -> %s (_key %s)", creation.From, creation.To, creation.Key), creation); err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d schema violations:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// collectionSchema is the "schema" property of an ArangoDB (3.7 or later) collection
+type collectionSchema struct {
+	Rule    interface{} `json:"rule"`
+	Level   string      `json:"level"`
+	Message string      `json:"message"`
+}
+
+// applyCollectionSchemas sets the schema rule of each collection, unless it already has the same one.
+// The driver doesn't know about collection schemas, so the properties are read and written through the HTTP API.
+func applyCollectionSchemas(conn driver.Connection, db driver.Database) ([]string, error) {
+	report := []string{}
+	for _, col := range schemaCollections {
+		var rule interface{}
+		data, err := json.Marshal(documentSchema(col.Type))
+		if err != nil {
+			return report, err
+		}
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return report, err
+		}
+		wanted := collectionSchema{
+			Rule:    rule,
+			Level:   SCHEMA_LEVEL,
+			Message: fmt.Sprintf("The document doesn't match the schema of the %s collection", col.Name),
+		}
+
+		propertiesPath := path.Join("_db", url.PathEscape(db.Name()), "_api", "collection", url.PathEscape(col.Name), "properties")
+		req, err := conn.NewRequest("GET", propertiesPath)
+		if err != nil {
+			return report, err
+		}
+		resp, err := conn.Do(nil, req)
+		if err != nil {
+			return report, err
+		}
+		if err := resp.CheckStatus(200); err != nil {
+			return report, fmt.Errorf("reading the properties of %s: %s", col.Name, err.Error())
+		}
+		var current *collectionSchema
+		if err := resp.ParseBody("schema", &current); err != nil {
+			return report, err
+		}
+		if current != nil && reflect.DeepEqual(current.Rule, wanted.Rule) && current.Level == wanted.Level {
+			report = append(report, fmt.Sprintf("schema rule of %s is up to date", col.Name))
+			continue
+		}
+
+		req, err = conn.NewRequest("PUT", propertiesPath)
+		if err != nil {
+			return report, err
+		}
+		if _, err := req.SetBody(map[string]interface{}{"schema": wanted}); err != nil {
+			return report, err
+		}
+		resp, err = conn.Do(nil, req)
+		if err != nil {
+			return report, err
+		}
+		if err := resp.CheckStatus(200); err != nil {
+			return report, fmt.Errorf("setting the schema rule of %s: %s", col.Name, err.Error())
+		}
+		report = append(report, fmt.Sprintf("set the schema rule of %s", col.Name))
+	}
+	return report, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDocumentSchemaRequired(t *testing.T) {
+	tests := []struct {
+		Doc      interface{}
+		Required []string
+	}{
+		{Claim{}, []string{"id", "truth"}},
+		{Argument{}, []string{"id", "relevance", "strength"}},
+		{Premise{}, []string{"order"}},
+		{Rating{}, []string{"node", "type", "value"}},
+		{User{}, []string{"id"}},
+		{Creation{}, nil},
+	}
+	for _, test := range tests {
+		schema := documentSchema(reflect.TypeOf(test.Doc))
+		required := append([]string{}, schema.Required...)
+		sort.Strings(required)
+		if !reflect.DeepEqual(required, test.Required) && len(required)+len(test.Required) > 0 {
+			t.Errorf("%T requires %v, expected %v", test.Doc, required, test.Required)
+		}
+	}
+
+	// A claim written by another tool, without the optional fields, is valid; one without a truth is not
+	schema := documentSchema(reflect.TypeOf(Claim{}))
+	problems := validateValue(schema, map[string]interface{}{"id": "c1", "truth": 0.5}, "")
+	if len(problems) > 0 {
+		t.Errorf("the claim without its optional fields has the problems %v", problems)
+	}
+	problems = validateValue(schema, map[string]interface{}{"id": "c1", "title": "Nuclear is clean."}, "")
+	if len(problems) != 1 || !strings.Contains(problems[0], `missing required field "truth"`) {
+		t.Errorf("the claim without a truth has the problems %v", problems)
+	}
+}
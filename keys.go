@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/google/uuid"
+)
+
+// keyNamespace is the namespace of the name-based UUIDs used as document keys
+var keyNamespace = uuid.MustParse("a85c2634-de90-47d9-81a2-cfd605dd93e0")
+
+// documentKey derives the _key of a document from its collection and a stable identifier
+// (the ID of the source node, or the ends of an edge), so that importing the same data again
+// produces the same keys, whatever the input format
+func documentKey(collection, id string) string {
+	return uuid.NewSHA1(keyNamespace, []byte(collection+"/"+id)).String()
+}
+
+// edgeKey derives the _key of an edge from the documents it connects
+func edgeKey(collection, from, to string) string {
+	return documentKey(collection, from+"->"+to)
+}
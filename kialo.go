@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var kialoLine = regexp.MustCompile(`^(\d+(?:\.\d+)*)\.\s*(?:(Pro|Con):\s*)?(.*)$`)
+var kialoSeeLink = regexp.MustCompile(`^->\s*See\s+(\d+(?:\.\d+)*)\.?\s*$`)
+
+const KIALO_TITLE_PREFIX = "Discussion Title:"
+
+// A kialoEntry is one numbered line of the outline, with any lines that continue its text
+type kialoEntry struct {
+	Number   string
+	Polarity int
+	Text     string
+	Link     string
+}
+
+func (entry kialoEntry) ParentNumber() string {
+	if i := strings.LastIndex(entry.Number, "."); i >= 0 {
+		return entry.Number[:i]
+	}
+	return ""
+}
+
+// kialoClaimID and kialoArgumentID give the IDs of the nodes created for an outline number.
+// The base Claim of each pro/con entry gets its own ID, next to the ID of the Argument.
+func kialoClaimID(number string) string {
+	return "kialo:" + number
+}
+
+func kialoArgumentID(number string) string {
+	return "kialo:" + number + ":arg"
+}
+
+// parseKialo converts a Kialo plain text export (a numbered outline with Pro:/Con: markers)
+// into Debate Map nodes, so the rest of the import can treat it like any other export:
+//   - The thesis ("1.") becomes the root Claim
+//   - Each "Pro:" or "Con:" entry becomes an Argument based on a Claim with the entry's text,
+//     targeting the Claim of its parent entry, in the order of their numbers
+//   - An entry that only says "-> See 1.2.3." is a cross-link, and its Argument is based on
+//     the existing Claim of entry 1.2.3 instead of a duplicate
+func parseKialo(data []byte) (DebateMapRoot, error) {
+	root := DebateMapRoot{}
+	entries := []*kialoEntry{}
+	byNumber := map[string]*kialoEntry{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimInput(data)))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	var last *kialoEntry
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || (last == nil && strings.HasPrefix(line, KIALO_TITLE_PREFIX)) {
+			continue
+		}
+		match := kialoLine.FindStringSubmatch(line)
+		if match == nil {
+			if last == nil {
+				return root, fmt.Errorf("line %d: expected a numbered Kialo entry, found %q", lineNumber, line)
+			}
+			// Continuation of a multi-line entry
+			last.Text += "\n" + line
+			continue
+		}
+
+		entry := &kialoEntry{Number: match[1], Text: strings.TrimSpace(match[3])}
+		switch match[2] {
+		case "Pro":
+			entry.Polarity = ARGUMENT_POLARITY_PRO
+		case "Con":
+			entry.Polarity = ARGUMENT_POLARITY_CON
+		}
+		if _, ok := byNumber[entry.Number]; ok {
+			return root, fmt.Errorf("line %d: duplicate Kialo entry %s", lineNumber, entry.Number)
+		}
+		if entry.ParentNumber() != "" {
+			if _, ok := byNumber[entry.ParentNumber()]; !ok {
+				return root, fmt.Errorf("line %d: Kialo entry %s comes before its parent %s", lineNumber, entry.Number, entry.ParentNumber())
+			}
+			if entry.Polarity == 0 {
+				fmt.Fprintf(logOutput, "----------------------------Kialo entry %s has no Pro: or Con: marker, treating it as Pro\n", entry.Number)
+				entry.Polarity = ARGUMENT_POLARITY_PRO
+			}
+		}
+		if link := kialoSeeLink.FindStringSubmatch(entry.Text); link != nil {
+			entry.Link = link[1]
+		}
+		entries = append(entries, entry)
+		byNumber[entry.Number] = entry
+		last = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return root, err
+	}
+
+	nodes := map[string]*DebateMapNode{}
+	order := []string{}
+	addNode := func(node *DebateMapNode) {
+		nodes[node.ID] = node
+		order = append(order, node.ID)
+	}
+
+	// Claims first, so that cross-links can point forward
+	claimIDs := map[string]string{}
+	for _, entry := range entries {
+		if entry.Link != "" {
+			continue
+		}
+		id := kialoClaimID(entry.Number)
+		claimIDs[entry.Number] = id
+		addNode(&DebateMapNode{
+			ID:       id,
+			Type:     NODE_TYPE_CLAIM,
+			Current:  Current{ID: id, Title: TitleSet{Base: entry.Text}},
+			Parents:  map[string]interface{}{},
+			Children: map[string]interface{}{},
+		})
+	}
+	for _, entry := range entries {
+		if entry.Link == "" {
+			continue
+		}
+		target, ok := byNumber[entry.Link]
+		for seen := 0; ok && target.Link != "" && seen < len(entries); seen++ {
+			target, ok = byNumber[target.Link]
+		}
+		if !ok || target.Link != "" {
+			return root, fmt.Errorf("Kialo entry %s links to unknown entry %s", entry.Number, entry.Link)
+		}
+		claimIDs[entry.Number] = claimIDs[target.Number]
+	}
+
+	for _, entry := range entries {
+		if entry.ParentNumber() == "" {
+			continue
+		}
+		claimID := claimIDs[entry.Number]
+		argID := kialoArgumentID(entry.Number)
+		addNode(&DebateMapNode{
+			ID:            argID,
+			Type:          NODE_TYPE_ARGUMENT,
+			Polarity:      entry.Polarity,
+			Current:       Current{ID: argID},
+			Parents:       map[string]interface{}{},
+			Children:      map[string]interface{}{claimID: Child{ID: claimID}},
+			ChildrenOrder: []string{claimID},
+		})
+		nodes[claimID].Parents[argID] = true
+
+		// The children of a cross-link respond to the linked Claim
+		parent := nodes[claimIDs[entry.ParentNumber()]]
+		parent.Children[argID] = Child{ID: argID, Polarity: entry.Polarity}
+		parent.ChildrenOrder = append(parent.ChildrenOrder, argID)
+		nodes[argID].Parents[parent.ID] = true
+	}
+
+	for _, id := range order {
+		root.Nodes = append(root.Nodes, *nodes[id])
+	}
+	fmt.Fprintf(logOutput, "Read %d Kialo entries into %d nodes\n", len(entries), len(root.Nodes))
+	return root, nil
+}
+
+// looksLikeKialo recognizes a Kialo export by its title line, or by starting with the "1." thesis
+func looksLikeKialo(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(trimInput(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		return strings.HasPrefix(line, KIALO_TITLE_PREFIX) || strings.HasPrefix(line, "1. ")
+	}
+	return false
+}
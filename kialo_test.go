@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testKialo = `Discussion Title: Should Brazil reform its pension system?
+
+1. Brazil should reform its pension system.
+1.1. Pro: The deficit keeps growing.
+1.1.1. Con: The numbers are disputed,
+and the official projections changed twice.
+1.2. Con: Reform hurts the poor.
+1.2.1. Con: -> See 1.1.1.
+1.2.1.1. Pro: Independent audits agree.
+`
+
+func TestParseKialo(t *testing.T) {
+	root, err := parseKialo([]byte(testKialo))
+	if err != nil {
+		t.Fatalf("parseKialo: %s", err.Error())
+	}
+	nodes := map[string]DebateMapNode{}
+	for _, node := range root.Nodes {
+		nodes[node.ID] = node
+	}
+	// 5 claims, as the cross-link has none of its own, and 5 arguments
+	if len(nodes) != 10 {
+		t.Fatalf("read %d nodes, expected 10", len(nodes))
+	}
+	if _, ok := nodes[kialoClaimID("1.2.1")]; ok {
+		t.Errorf("the cross-link got a claim of its own")
+	}
+	if title := nodes[kialoClaimID("1.1.1")].Current.Title.Base; title != "The numbers are disputed,\nand the official projections changed twice." {
+		t.Errorf("read the multi-line entry as %q", title)
+	}
+
+	tests := []struct {
+		Number   string
+		Parent   string
+		Claim    string
+		Polarity int
+	}{
+		{"1.1", kialoClaimID("1"), kialoClaimID("1.1"), ARGUMENT_POLARITY_PRO},
+		{"1.1.1", kialoClaimID("1.1"), kialoClaimID("1.1.1"), ARGUMENT_POLARITY_CON},
+		{"1.2", kialoClaimID("1"), kialoClaimID("1.2"), ARGUMENT_POLARITY_CON},
+		{"1.2.1", kialoClaimID("1.2"), kialoClaimID("1.1.1"), ARGUMENT_POLARITY_CON},
+		// The children of a cross-link respond to the linked claim
+		{"1.2.1.1", kialoClaimID("1.1.1"), kialoClaimID("1.2.1.1"), ARGUMENT_POLARITY_PRO},
+	}
+	for _, test := range tests {
+		arg := nodes[kialoArgumentID(test.Number)]
+		if arg.Type != NODE_TYPE_ARGUMENT || arg.Polarity != test.Polarity {
+			t.Errorf("unexpected argument for %s: %+v", test.Number, arg)
+		}
+		if _, ok := arg.Children[test.Claim]; !ok || len(arg.Children) != 1 {
+			t.Errorf("the argument for %s is based on %v, expected %s", test.Number, arg.Children, test.Claim)
+		}
+		if child := NewChildFromData(arg.ID, nodes[test.Parent].Children[arg.ID]); child == nil || child.Polarity != test.Polarity {
+			t.Errorf("the argument for %s is not a child of %s with polarity %d", test.Number, test.Parent, test.Polarity)
+		}
+	}
+
+	g := buildGraph(root)
+	if err := checkUniqueIndexes(g); err != nil {
+		t.Fatalf("checkUniqueIndexes: %s", err.Error())
+	}
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+}
+
+func TestParseKialoErrors(t *testing.T) {
+	tests := []struct {
+		Doc   string
+		Error string
+	}{
+		{"Some notes\n1. A thesis.", "expected a numbered Kialo entry"},
+		{"1. A thesis.\n1.1. Pro: A reason.\n1.1. Con: Another reason.", "duplicate Kialo entry 1.1"},
+		{"1. A thesis.\n1.1.1. Pro: A reason.", "comes before its parent 1.1"},
+		{"1. A thesis.\n1.1. Pro: -> See 1.5.", "links to unknown entry 1.5"},
+	}
+	for _, test := range tests {
+		_, err := parseKialo([]byte(test.Doc))
+		if err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("parseKialo(%q) returned the error %v, expected %q", test.Doc, err, test.Error)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+// Titles with fewer words than this are too short to tell their language,
+// and take the language of their parent in the debate instead.
+// Shorter ones are often taken for another language with full confidence ("Nuclear emits little CO2." is French).
+const LANGUAGE_MIN_WORDS = 8
+
+// The detector must be at least this confident for a guess to be reliable.
+// It is stricter than the detector's own threshold (0.8), as a wrong guess is spread to the children.
+const LANGUAGE_MIN_CONFIDENCE = 0.9
+
+// The languages that can be detected (ISO 639-1 codes), with the ArangoSearch analyzer of their text.
+// Detection is limited to them, so every tagged document has an analyzer for its language.
+var languages = []struct {
+	Code     string
+	Lang     whatlanggo.Lang
+	Analyzer string
+}{
+	{"en", whatlanggo.Eng, "text_en"},
+	{"pt", whatlanggo.Por, "text_pt"},
+	{"es", whatlanggo.Spa, "text_es"},
+	{"fr", whatlanggo.Fra, "text_fr"},
+	{"de", whatlanggo.Deu, "text_de"},
+	{"it", whatlanggo.Ita, "text_it"},
+	{"nl", whatlanggo.Nld, "text_nl"},
+	{"sv", whatlanggo.Swe, "text_sv"},
+	{"no", whatlanggo.Nob, "text_no"},
+	{"fi", whatlanggo.Fin, "text_fi"},
+	{"ru", whatlanggo.Rus, "text_ru"},
+	{"zh", whatlanggo.Cmn, "text_zh"},
+}
+
+// detectLanguage guesses the language of the texts of a document. The guess is reliable if the texts have
+// at least LANGUAGE_MIN_WORDS words and the detector is at least LANGUAGE_MIN_CONFIDENCE confident;
+// it is empty if the language is not one of the languages.
+func detectLanguage(texts ...string) (string, bool) {
+	parts := []string{}
+	for _, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	text := strings.Join(parts, ". ")
+	if text == "" {
+		return "", false
+	}
+
+	options := whatlanggo.Options{Whitelist: map[whatlanggo.Lang]bool{}}
+	for _, language := range languages {
+		options.Whitelist[language.Lang] = true
+	}
+	info := whatlanggo.DetectWithOptions(text, options)
+	for _, language := range languages {
+		if language.Lang == info.Lang {
+			return language.Code, info.Confidence >= LANGUAGE_MIN_CONFIDENCE && len(strings.Fields(text)) >= LANGUAGE_MIN_WORDS
+		}
+	}
+	return "", false
+}
+
+// detectLanguages tags the Claims and Arguments with their language.
+// The debates are walked from their roots along the edges, so that a document whose language can't be told
+// reliably (a short title, or an argument without one) takes the language of its parent.
+// A root that can't be told takes the most common language among the documents of its debate that can,
+// or else among all the documents (a category named "Science" is most likely in the language of the others).
+func detectLanguages(g *Graph) {
+	guesses := map[string]string{}
+	reliable := map[string]bool{}
+	order := []string{}
+	for _, claim := range g.Claims {
+		guesses[claim.ArangoID()], reliable[claim.ArangoID()] = detectLanguage(claim.Title, claim.Negation, claim.Question)
+		order = append(order, claim.ArangoID())
+	}
+	for _, arg := range g.Arguments {
+		guesses[arg.ArangoID()], reliable[arg.ArangoID()] = detectLanguage(arg.Title, arg.Negation, arg.Question)
+		order = append(order, arg.ArangoID())
+	}
+
+	children := map[string][]string{}
+	hasParent := map[string]bool{}
+	addEdge := func(from, to string) {
+		children[from] = append(children[from], to)
+		hasParent[to] = true
+	}
+	for _, inference := range g.Inferences {
+		addEdge(inference.From, inference.To)
+	}
+	for _, bc := range g.BaseClaims {
+		addEdge(bc.From, bc.To)
+	}
+	for _, premise := range g.Premises {
+		addEdge(premise.From, premise.To)
+	}
+
+	// Roots first, then whatever is only reachable through a cycle
+	roots := []string{}
+	for _, id := range order {
+		if !hasParent[id] {
+			roots = append(roots, id)
+		}
+	}
+	roots = append(roots, order...)
+
+	mostCommon := func(counts map[string]int, fallback string) string {
+		best := fallback
+		for _, language := range languages {
+			if counts[language.Code] > counts[best] {
+				best = language.Code
+			}
+		}
+		return best
+	}
+	overall := map[string]int{}
+	for id, language := range guesses {
+		if reliable[id] {
+			overall[language]++
+		}
+	}
+	debateLanguage := func(root string) string {
+		counts := map[string]int{}
+		seen := map[string]bool{root: true}
+		queue := []string{root}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			if reliable[id] {
+				counts[guesses[id]]++
+			}
+			for _, child := range children[id] {
+				if !seen[child] {
+					seen[child] = true
+					queue = append(queue, child)
+				}
+			}
+		}
+		if len(counts) == 0 {
+			return mostCommon(overall, guesses[root])
+		}
+		return mostCommon(counts, guesses[root])
+	}
+
+	found := map[string]string{}
+	inherited := 0
+	type visit struct {
+		ID     string
+		Parent string
+	}
+	for _, root := range roots {
+		if _, ok := found[root]; ok {
+			continue
+		}
+		if !reliable[root] {
+			guesses[root] = debateLanguage(root)
+		}
+		queue := []visit{{ID: root}}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			if _, ok := found[v.ID]; ok {
+				continue
+			}
+			language := guesses[v.ID]
+			if !reliable[v.ID] && v.Parent != "" {
+				language = v.Parent
+				inherited++
+			}
+			found[v.ID] = language
+			for _, child := range children[v.ID] {
+				queue = append(queue, visit{ID: child, Parent: language})
+			}
+		}
+	}
+
+	counts := map[string]int{}
+	for i, claim := range g.Claims {
+		g.Claims[i].Language = found[claim.ArangoID()]
+		counts[g.Claims[i].Language]++
+	}
+	for i, arg := range g.Arguments {
+		g.Arguments[i].Language = found[arg.ArangoID()]
+		counts[g.Arguments[i].Language]++
+	}
+
+	summary := []string{}
+	for code, count := range counts {
+		if code == "" {
+			code = "unknown"
+		}
+		summary = append(summary, fmt.Sprintf("%s %d", code, count))
+	}
+	sort.Strings(summary)
+	fmt.Fprintf(logOutput, "Languages: %s (%d inherited from the parent)\n", strings.Join(summary, ", "), inherited)
+}
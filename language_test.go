@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		Title    string
+		Code     string
+		Reliable bool
+	}{
+		{"The study was performed by a single person, which increases the chances of bias and error.", "en", true},
+		{"Os valores exigidos para a contribuição no INSS são injustos.", "pt", true},
+		// Short titles are not reliable, whatever the detector guesses
+		{"Nuclear emits little CO2.", "", false},
+		{"Climate change is caused by humans.", "", false},
+		{"Milankovich Cycles explain changes in weather", "", false},
+		{"Os militares não têm direito ao FGTS.", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		code, reliable := detectLanguage(test.Title)
+		if reliable != test.Reliable || (test.Reliable && code != test.Code) {
+			t.Errorf("detectLanguage(%q) = %q, %v, expected %q, %v", test.Title, code, reliable, test.Code, test.Reliable)
+		}
+	}
+}
+
+func TestDetectLanguagesInherited(t *testing.T) {
+	root := DebateMapRoot{Nodes: []DebateMapNode{
+		claimNode("PT", "A Previdência Social, no modelo que temos hoje, é um direito social. A capitalização individual quebra com a garantia de proteção.", map[string]interface{}{
+			"PT1": map[string]interface{}{"polarity": float64(ARGUMENT_POLARITY_PRO)},
+		}),
+		claimNode("PT1", "Os militares não têm direito ao FGTS.", map[string]interface{}{}),
+		claimNode("EN", "The study was performed by a single person, which increases the chances of bias and error.", map[string]interface{}{
+			"EN1": map[string]interface{}{"polarity": float64(ARGUMENT_POLARITY_CON)},
+		}),
+		claimNode("EN1", "Nuclear emits little CO2.", map[string]interface{}{}),
+	}}
+
+	g := buildGraph(root)
+	expected := map[string]string{"PT": "pt", "PT1": "pt", "EN": "en", "EN1": "en"}
+	for _, claim := range g.Claims {
+		if claim.Language != expected[claim.ID] {
+			t.Errorf("claim %s is in %q, expected %q", claim.ID, claim.Language, expected[claim.ID])
+		}
+	}
+	for _, arg := range g.Arguments {
+		if language := expected[arg.ClaimID]; arg.Language != language {
+			t.Errorf("argument %s is in %q, expected %q", arg.ID, arg.Language, language)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// STDIN_FILENAME can be passed with -f to read the data from the standard input
+const STDIN_FILENAME = "-"
+
+var magicGzip = []byte{0x1f, 0x8b}
+var magicZstd = []byte{0x28, 0xb5, 0x2f, 0xfd}
+var magicZip = []byte{'P', 'K', 0x03, 0x04}
+
+// An empty zip archive only has the end of its (empty) central directory
+var magicZipEmpty = []byte{'P', 'K', 0x05, 0x06}
+
+// An InputFile is one export file to be imported.
+// A single filename can produce several of them when it is a zip archive.
+type InputFile struct {
+	Name string
+	Data []byte
+}
+
+// loadInputs reads the named file (or stdin, for "-"), transparently decompressing
+// gzip and zstd data and expanding zip archives into one InputFile per member
+func loadInputs(filename string) ([]InputFile, error) {
+	var data []byte
+	var err error
+	if filename == STDIN_FILENAME {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return expandInput(filename, data)
+}
+
+func expandInput(name string, data []byte) ([]InputFile, error) {
+	switch {
+	case bytes.HasPrefix(data, magicGzip):
+		fmt.Fprintln(logOutput, "Decompressing gzip data:", name)
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		defer r.Close()
+		data, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		return expandInput(strings.TrimSuffix(name, ".gz"), data)
+	case bytes.HasPrefix(data, magicZstd):
+		fmt.Fprintln(logOutput, "Decompressing zstd data:", name)
+		r, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		defer r.Close()
+		data, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		return expandInput(strings.TrimSuffix(name, ".zst"), data)
+	case bytes.HasPrefix(data, magicZip) || bytes.HasPrefix(data, magicZipEmpty):
+		return expandZip(name, data)
+	default:
+		return []InputFile{{Name: name, Data: data}}, nil
+	}
+}
+
+// expandZip returns every regular file in the archive (in archive order),
+// skipping directories and the metadata added by macOS
+func expandZip(name string, data []byte) ([]InputFile, error) {
+	fmt.Fprintln(logOutput, "Reading zip archive:", name)
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err.Error())
+	}
+	inputs := []InputFile{}
+	for _, member := range archive.File {
+		if member.FileInfo().IsDir() || strings.HasPrefix(member.Name, "__MACOSX/") || strings.HasPrefix(path.Base(member.Name), ".") {
+			continue
+		}
+		r, err := member.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %s", name, member.Name, err.Error())
+		}
+		memberData, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %s", name, member.Name, err.Error())
+		}
+		expanded, err := expandInput(name+"/"+member.Name, memberData)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, expanded...)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%s: zip archive contains no files", name)
+	}
+	return inputs, nil
+}
+
+// InputOptions controls how the export files are parsed
+type InputOptions struct {
+	// Format is one of the FORMAT_ constants, or FORMAT_UNKNOWN to detect it from the data
+	Format int
+	// FirebaseVersion selects the version root of a Firebase export (e.g. "v12-prod").
+	// If empty, the most recent version is used.
+	FirebaseVersion string
+}
+
+// parseInput converts one export file into a DebateMapRoot.
+// Data in the NODES format only fills in the Nodes of the result.
+func parseInput(input InputFile, opts InputOptions) (DebateMapRoot, error) {
+	root := DebateMapRoot{}
+	data := trimInput(input.Data)
+	format := opts.Format
+
+	if format == FORMAT_UNKNOWN {
+		var err error
+		format, err = detectInputFormat(InputFile{Name: input.Name, Data: data})
+		if err != nil {
+			return root, err
+		}
+		fmt.Fprintf(logOutput, "---------------------Detected data in %s format: %s\n", strings.ToUpper(formatName(format)), input.Name)
+	} else {
+		fmt.Fprintf(logOutput, "---------------------Using data in %s format: %s\n", strings.ToUpper(formatName(format)), input.Name)
+	}
+
+	switch format {
+	case FORMAT_GENERAL:
+		if err := json.Unmarshal(data, &root); err != nil {
+			return root, fmt.Errorf("error parsing JSON: %s", err.Error())
+		}
+	case FORMAT_NODES:
+		if err := json.Unmarshal(data, &root.Nodes); err != nil {
+			return root, fmt.Errorf("error parsing JSON: %s", err.Error())
+		}
+	case FORMAT_FIREBASE:
+		return parseFirebaseExport(data, opts.FirebaseVersion)
+	case FORMAT_AIF:
+		return parseAIF(data)
+	case FORMAT_ARGDOWN:
+		return parseArgdown(data)
+	case FORMAT_KIALO:
+		return parseKialo(data)
+	case FORMAT_CSV:
+		return parseCSV(data)
+	default:
+		return root, fmt.Errorf("unsupported format %d", format)
+	}
+	return root, nil
+}
+
+// Merge appends the contents of another export to this one
+func (root *DebateMapRoot) Merge(other DebateMapRoot) {
+	root.Maps = append(root.Maps, other.Maps...)
+	root.Nodes = append(root.Nodes, other.Nodes...)
+	root.NodeRevisions = append(root.NodeRevisions, other.NodeRevisions...)
+	root.NodeRatings = append(root.NodeRatings, other.NodeRatings...)
+	root.Users = append(root.Users, other.Users...)
+	root.UserExtras = append(root.UserExtras, other.UserExtras...)
+}
+
+// loadRoot loads and parses every export file in the named file, merging them into one DebateMapRoot
+func loadRoot(filename string, opts InputOptions) DebateMapRoot {
+	fmt.Fprintln(logOutput, "Loading file:", filename)
+	inputs, err := loadInputs(filename)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error loading file:", err.Error())
+		panic(err.Error())
+	}
+
+	// Convert the data to nodes
+	general := DebateMapRoot{}
+	for _, input := range inputs {
+		root, err := parseInput(input, opts)
+		if err != nil {
+			fmt.Fprintf(logOutput, "Error reading %s: %s\n", input.Name, err.Error())
+			fmt.Fprintln(logOutput, "Use --format to choose one of:", strings.Join(formatNameList()[1:], ", "))
+			panic(err.Error())
+		}
+		general.Merge(root)
+	}
+	return general
+}
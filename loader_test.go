@@ -0,0 +1,136 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	w := gzip.NewWriter(b)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("gzip: %s", err.Error())
+	}
+	w.Close()
+	return b.Bytes()
+}
+
+func zstdData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd: %s", err.Error())
+	}
+	defer w.Close()
+	return w.EncodeAll(data, nil)
+}
+
+// zipData archives the files, in order, under their names
+func zipData(t *testing.T, files ...InputFile) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	w := zip.NewWriter(b)
+	for _, file := range files {
+		f, err := w.Create(file.Name)
+		if err != nil {
+			t.Fatalf("zip: %s", err.Error())
+		}
+		if _, err := f.Write(file.Data); err != nil {
+			t.Fatalf("zip: %s", err.Error())
+		}
+	}
+	w.Close()
+	return b.Bytes()
+}
+
+func nodeIDs(root DebateMapRoot) []string {
+	ids := []string{}
+	for _, node := range root.Nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func TestLoadRootCompressed(t *testing.T) {
+	data, err := os.ReadFile(DEFAULT_FILENAME)
+	if err != nil {
+		t.Fatalf("reading %s: %s", DEFAULT_FILENAME, err.Error())
+	}
+	opts := InputOptions{Format: FORMAT_UNKNOWN}
+	expected := nodeIDs(loadRoot(DEFAULT_FILENAME, opts))
+	if len(expected) == 0 {
+		t.Fatalf("read no nodes from %s", DEFAULT_FILENAME)
+	}
+
+	dir := t.TempDir()
+	tests := []struct {
+		Name string
+		Data []byte
+	}{
+		{"Test1.json.gz", gzipData(t, data)},
+		{"Test1.json.zst", zstdData(t, data)},
+		{"Test1.zip", zipData(t, InputFile{Name: "Test1.json", Data: data})},
+		// A compressed file in an archive, with the metadata macOS adds
+		{"Test1-macos.zip", zipData(t, InputFile{Name: "__MACOSX/._Test1.json.gz", Data: []byte("metadata")},
+			InputFile{Name: "Test1.json.gz", Data: gzipData(t, data)})},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(dir, test.Name)
+		if err := os.WriteFile(filename, test.Data, 0644); err != nil {
+			t.Fatalf("writing %s: %s", test.Name, err.Error())
+		}
+		if ids := nodeIDs(loadRoot(filename, opts)); !reflect.DeepEqual(ids, expected) {
+			t.Errorf("read %d nodes from %s, expected the %d of %s", len(ids), test.Name, len(expected), DEFAULT_FILENAME)
+		}
+	}
+
+	// -f - reads the standard input
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	f, err := os.Open(filepath.Join(dir, "Test1.json.gz"))
+	if err != nil {
+		t.Fatalf("opening Test1.json.gz: %s", err.Error())
+	}
+	defer f.Close()
+	os.Stdin = f
+	if ids := nodeIDs(loadRoot(STDIN_FILENAME, opts)); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("read %d nodes from the standard input, expected the %d of %s", len(ids), len(expected), DEFAULT_FILENAME)
+	}
+}
+
+func TestExpandZip(t *testing.T) {
+	// Every file of the archive is an input, in archive order
+	inputs, err := expandInput("debates.zip", zipData(t,
+		InputFile{Name: "a.json", Data: []byte(`[{"_key": "A"}]`)},
+		InputFile{Name: "docs/", Data: nil},
+		InputFile{Name: "docs/.DS_Store", Data: []byte("metadata")},
+		InputFile{Name: "docs/b.json.gz", Data: gzipData(t, []byte(`[{"_key": "B"}]`))}))
+	if err != nil {
+		t.Fatalf("expandInput: %s", err.Error())
+	}
+	names := []string{}
+	for _, input := range inputs {
+		names = append(names, input.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"debates.zip/a.json", "debates.zip/docs/b.json"}) {
+		t.Errorf("expanded the archive into %v", names)
+	}
+	if string(inputs[1].Data) != `[{"_key": "B"}]` {
+		t.Errorf("read %q from b.json.gz", inputs[1].Data)
+	}
+
+	for _, files := range [][]InputFile{nil, {{Name: "__MACOSX/._a.json", Data: []byte("metadata")}}} {
+		if _, err := expandInput("empty.zip", zipData(t, files...)); err == nil || !strings.Contains(err.Error(), "zip archive contains no files") {
+			t.Errorf("expandInput of an archive with %d metadata files returned the error %v", len(files), err)
+		}
+	}
+}
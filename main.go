@@ -1,15 +1,13 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"os"
 	"strings"
 
 	driver "github.com/arangodb/go-driver"
 	"github.com/arangodb/go-driver/http"
-	"github.com/google/uuid"
 )
 
 const DEFAULT_FILENAME = "data/Test1.json"
@@ -18,379 +16,249 @@ const DEFAULT_DB = "canonical_debate"
 const DEFAULT_USERNAME = "root"
 const DEFAULT_PASSWORD = ""
 
-const FORMAT_UNKNOWN int = 0
-const FORMAT_NODES int = 1
-const FORMAT_GENERAL int = 2
-
 func main() {
-	fmt.Println("Starting data migration")
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
 
-	var filename, server, dbname, username, password string
-	flag.StringVar(&filename, "f", DEFAULT_FILENAME, "filename")
-	flag.StringVar(&server, "h", DEFAULT_SERVER, "host (e.g. http://localhost:8529)")
-	flag.StringVar(&dbname, "db", DEFAULT_DB, "DB name")
-	flag.StringVar(&username, "u", DEFAULT_USERNAME, "username")
-	flag.StringVar(&password, "p", DEFAULT_PASSWORD, "password")
+	fmt.Fprintln(logOutput, "Starting data migration")
+
+	var filename, formatFlag, firebaseVersion, sinkName, dedupFlag, scorerName, accountsFile string
+	cf := ConnectionFlags{}
+	cf.Register(flag.CommandLine)
+	opts := SinkOptions{}
+	opts.Schema.Register(flag.CommandLine)
+	anonymize := AnonymizeFlags{}
+	anonymize.Register(flag.CommandLine)
+	flag.StringVar(&filename, "f", DEFAULT_FILENAME, "filename (gzip, zstd and zip are decompressed; \"-\" reads stdin)")
+	flag.StringVar(&formatFlag, "format", FORMAT_AUTO_NAME, "input format ("+strings.Join(formatNameList(), ", ")+")")
+	flag.StringVar(&firebaseVersion, "fb-version", "", "version root of a Firebase export (e.g. v12-prod; default: most recent)")
+	flag.StringVar(&dedupFlag, "dedup", DEDUP_OFF, "merge the claims with the same text into one ("+strings.Join(dedupModes, ", ")+")")
+	flag.StringVar(&scorerName, "score", SCORER_NONE, "compute the scores of the claims and arguments instead of starting them all equal ("+strings.Join(scorerNames(), ", ")+")")
+	flag.StringVar(&accountsFile, "accounts", "", "CSV file mapping the Firebase UIDs of the users to their accounts (columns uid and account)")
+	flag.StringVar(&sinkName, "sink", SINK_ARANGO, "where to write the graph ("+strings.Join(sinkNames, ", ")+")")
+	flag.StringVar(&opts.Output, "o", "", "output of the sink: file for cypher (\"-\" for stdout), directory for neo4j-csv (default "+DEFAULT_NEO4J_DIR+"), database file for sqlite (default "+DEFAULT_SQLITE_FILE+"), URL for postgres")
+	flag.StringVar(&opts.DDL, "ddl", "", "also write the generated schema of the sqlite and postgres sinks to this file (\"-\" for stdout)")
+	flag.BoolVar(&opts.EnsureSchema, "ensure-schema", false, "create the database, collections and graph that are missing before writing (arango sink)")
 	//filename := "data/Test1.json"
 	//filename := "data/small_test.json"
 	//filename := "data/single_test.json"
 	flag.Parse()
 
-	db, _ := OpenArangoConnection(server, dbname, username, password)
-
-	// Open collections for vertices
-	colClaims := openCollection(db, "claims", true)
-	colArgs := openCollection(db, "arguments", true)
-
-	fmt.Println("Loading file:", filename)
-	file, err := ioutil.ReadFile(filename)
+	format, err := parseFormatName(formatFlag)
 	if err != nil {
-		fmt.Println("Error loading file:", err.Error())
+		fmt.Fprintln(logOutput, "Error in --format:", err.Error())
 		panic(err.Error())
 	}
-
-	var format int
-	if strings.HasPrefix(string(file), `[{"children":{`) {
-		fmt.Println("---------------------Detected data in NODES format")
-		format = FORMAT_NODES
-	} else if strings.HasPrefix(string(file), `{"general":`) {
-		fmt.Println("---------------------Detected data in GENERAL format")
-		format = FORMAT_GENERAL
-	} else {
-		fmt.Println("---------------------Data is in unknown format")
-		format = FORMAT_UNKNOWN
+	dedupMode, err := parseDedupMode(dedupFlag)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error in --dedup:", err.Error())
+		panic(err.Error())
 	}
-
-	/*
-		m := map[string]interface{}{}
-		json.Unmarshal(file, &m)
-
-		for k, _ := range m {
-			fmt.Printf("Root key: %+v\n", k)
-		}
-
-		data := m["maps"].([]interface{})
-		for _, node := range data {
-			mnode := node.(map[string]interface{})
-			//delete(mnode, "_key")
-			fmt.Printf("Read mnode: %+v\n", mnode)
-		}
-	*/
-
-	// Convert the JSON to nodes
-	data := []DebateMapNode{}
-	revisions := map[string]NodeRevision{}
-	maps := map[string]DebateMapMap{}
-	if format == FORMAT_GENERAL {
-		general := DebateMapRoot{}
-		err = json.Unmarshal(file, &general)
-		if err != nil {
-			fmt.Println("Error parsing JSON:", err.Error())
-			panic(err.Error())
-		}
-		data = general.Nodes
-		revs := general.NodeRevisions
-		for _, rev := range revs {
-			revisions[rev.ID] = rev
-		}
-		for _, dmm := range general.Maps {
-			maps[dmm.RootNode] = dmm
-		}
-	} else {
-		err = json.Unmarshal(file, &data)
-		if err != nil {
-			fmt.Println("Error parsing JSON:", err.Error())
+	scorer, err := parseScorer(scorerName)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error in --score:", err.Error())
+		panic(err.Error())
+	}
+	if err := anonymize.Check(); err != nil {
+		fmt.Fprintln(logOutput, "Error in --anonymize:", err.Error())
+		panic(err.Error())
+	}
+	var accounts map[string]string
+	if accountsFile != "" {
+		if accounts, err = loadAccounts(accountsFile); err != nil {
+			fmt.Fprintln(logOutput, "Error in --accounts:", err.Error())
 			panic(err.Error())
 		}
 	}
+	sink := openSink(sinkName, opts, cf)
 
-	// First pass: create Claims and Arguments
-	claims := make(map[string]Claim)
-	args := make(map[string]Argument)
-	newClaims := []DebateMapNode{}
-	for i, node := range data {
-		if node.ID == "" {
-			node.ID = node.Current.ID
-			data[i] = node
-		}
-		if format == FORMAT_GENERAL {
-			rev := revisions[node.CurrentRevision]
-			node.Current.Title = rev.Title
-		}
-		fmt.Printf("Read node: %+v\n", node)
-		switch node.Type {
-		case NODE_TYPE_CLAIM:
-			claim := NewClaim(node)
-			claims[claim.ID] = claim
-			createItem(colClaims, claim)
-		case NODE_TYPE_ARGUMENT:
-			if node.MultiPremise {
-				// In Debate Map, it's the Arguments that are MP
-				// In this graph, it will be an MP Claim instead, which needs to be created
-				if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
-					fmt.Println("----------------------------L0Wv33MFQiuWVbWEKcELsA is MPClaim")
-				}
-
-				// Replace the new node with claim and arg nodes
-				argNode, claimNode := node.ConvertToMPClaim()
-				data[i] = argNode
-				newClaims = append(newClaims, claimNode)
-				if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
-					fmt.Println("----------------------------For MPClaim L0Wv33MFQiuWVbWEKcELsA created claimNode", claimNode.ID)
-					fmt.Println("----------------------------For MPClaim L0Wv33MFQiuWVbWEKcELsA created argNode", argNode.ID)
-				}
-
-				claim := NewClaim(claimNode)
-				claims[claim.ID] = claim
-				createItem(colClaims, claim)
-
-				argument := NewArgument(argNode)
-				argument.ClaimID = claim.ID
-				args[argument.ID] = argument
-				createItem(colArgs, argument)
-			} else {
-				argument := NewArgument(node)
-				args[argument.ID] = argument
-				if argument.ID == "L0Wv33MFQiuWVbWEKcELsA" {
-					fmt.Println("----------------------------Added L0Wv33MFQiuWVbWEKcELsA to args")
-				}
-				createItem(colArgs, argument)
-			}
-		case NODE_TYPE_CATEGORY, NODE_TYPE_PACKAGE, NODE_TYPE_QUESTION:
-			// Just to capture node information, these "debate" placeholders will be converted into
-			// a claim and (if there's a parent node) an argument
-			// They will require manual curation later to make them match the CD concepts
-			if dmm, ok := maps[node.ID]; ok {
-				node.Current.Title.Base = dmm.Name
-			}
-			argNode, claimNode := node.ConvertToClaimAndArg()
-			if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
-				fmt.Println("----------------------------L0Wv33MFQiuWVbWEKcELsA is a category, package or question")
-				fmt.Println("----------------------------For L0Wv33MFQiuWVbWEKcELsA created claimNode", claimNode.ID)
-			}
-
-			data[i] = claimNode
-
-			claim := NewClaim(claimNode)
-			claims[claim.ID] = claim
-			createItem(colClaims, claim)
+	general := loadRoot(filename, InputOptions{Format: format, FirebaseVersion: firebaseVersion})
+	g := buildGraph(general)
+	dedupClaims(g, dedupMode)
+	if accounts != nil {
+		linkAccounts(g, accounts)
+	}
+	if scorer != nil {
+		scoreGraph(g, scorerName, scorer)
+	}
+	anonymize.Apply(g)
+	if err := validateGraph(g); err != nil {
+		fmt.Fprintln(logOutput, "Error validating the graph:", err.Error())
+		panic(err.Error())
+	}
+	if err := checkUniqueIndexes(g); err != nil {
+		fmt.Fprintln(logOutput, "Error validating the graph:", err.Error())
+		panic(err.Error())
+	}
 
-			if argNode != nil {
-				data[i] = *argNode
-				newClaims = append(newClaims, claimNode)
-				if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
-					fmt.Println("----------------------------For L0Wv33MFQiuWVbWEKcELsA added claimNodeto newClaims")
-					fmt.Println("----------------------------For L0Wv33MFQiuWVbWEKcELsA created argNode", argNode.ID)
-				}
+	sink(g)
 
-				argument := NewArgument(*argNode)
-				argument.ClaimID = claim.ID
-				args[argument.ID] = argument
-				createItem(colArgs, argument)
-			}
-		}
-	}
-	data = append(data, newClaims...)
+	fmt.Fprintln(logOutput, "Done.")
+}
 
-	// Open collections for edges
-	edgeInferences := openCollection(db, "inferences", true)
-	edgeBaseClaims := openCollection(db, "base_claims", true)
-	edgePremises := openCollection(db, "premises", true)
+func OpenArangoConnection(server, dbname, username, password string) (driver.Database, error) {
+	c := OpenArangoClient(server, username, password)
 
-	// Second pass: create edges
-	for _, node := range data {
-		fmt.Printf("Read item for edges: %+v\n", node)
-		switch node.Type {
-		case NODE_TYPE_CLAIM:
-			nodeClaim, ok := claims[node.ID]
-			if !ok {
-				panic(fmt.Sprintf("Node claim %s not found", node.ID))
-			}
-			if node.MultiPremise {
-				if len(node.Children) == 0 {
-					fmt.Println("----------------------------MPClaim has no children")
-				}
-				for key, childVal := range node.Children {
-					child := NewChildFromData(key, childVal)
-					if child != nil {
-						if claim, ok := claims[child.ID]; ok {
-							createPremise(edgePremises, nodeClaim.ArangoID(), claim, node.ChildOrder(child.ID))
-						} else {
-							panic(fmt.Sprintf("Child Premise %s not found", child.ID))
-						}
-					} else {
-						fmt.Println("----------------------------Premise child from data is nil")
-					}
-				}
-			} else {
-				if len(node.Children) == 0 {
-					fmt.Println("----------------------------Claim has no children")
-				}
-				for key, childVal := range node.Children {
-					child := NewChildFromData(key, childVal)
-					if child != nil {
-						id := nodeClaim.ID
-						if arg, ok := args[child.ID]; ok {
-							arg.TargetClaimID = &id
-							arg.Pro = child.IsPro()
-							updateItem(colArgs, arg.Key, arg)
-							args[child.ID] = arg
-							createInference(edgeInferences, nodeClaim.ArangoID(), arg)
-						} else if claim, ok := claims[child.ID]; ok {
-							// Data consistency problem in the Debate Map version!
-							// Create an intervening Argument to resolve the problem
-							arg := Argument{
-								Key:           uuid.New().String(),
-								ID:            child.ID,
-								TargetClaimID: &id,
-								ClaimID:       claim.ID,
-								CreatedAt:     claim.CreatedAt,
-								Creator:       claim.Creator,
-								Pro:           child.IsPro(),
-								Relevance:     1.00,
-								Str:           0.50,
-							}
-							createItem(colArgs, arg)
-							createInference(edgeInferences, nodeClaim.ArangoID(), arg)
-							createBaseClaim(edgeBaseClaims, arg, claim.ArangoID())
-						} else {
-							panic(fmt.Sprintf("Child Argument %s not found", child.ID))
-						}
-					} else {
-						fmt.Println("----------------------------Claim child from data is nil")
-					}
-				}
-			}
-		case NODE_TYPE_ARGUMENT:
-			nodeArg, ok := args[node.ID]
-			if !ok {
-				panic(fmt.Sprintf("Node argument %s not found", node.ID))
-			}
-			if len(node.Children) == 0 {
-				fmt.Println("----------------------------Argument has no children")
-			}
-			for key, childVal := range node.Children {
-				child := NewChildFromData(key, childVal)
-				if child != nil {
-					id := nodeArg.ID
-					if arg, ok := args[child.ID]; ok {
-						arg.TargetArgumentID = &id
-						arg.Pro = child.IsPro()
-						updateItem(colArgs, arg.Key, arg)
-						args[child.ID] = arg
-						createInference(edgeInferences, nodeArg.ArangoID(), arg)
-					} else if claim, ok := claims[child.ID]; ok {
-						nodeArg.ClaimID = claim.ID
-						updateItem(colArgs, nodeArg.Key, nodeArg)
-						args[node.ID] = nodeArg
-						createBaseClaim(edgeBaseClaims, nodeArg, claim.ArangoID())
-					} else {
-						panic(fmt.Sprintf("Child %s not found", child.ID))
-					}
-				} else {
-					fmt.Println("----------------------------Argument child from data is nil")
-				}
-			}
-		}
+	fmt.Fprintln(logOutput, "Choosing the database:", dbname)
+	db, err := c.Database(nil, dbname)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error choosing the database:", err.Error())
+		panic(err.Error())
 	}
 
-	fmt.Println("Done.")
+	return db, err
 }
 
-func OpenArangoConnection(server, dbname, username, password string) (driver.Database, error) {
+func OpenArangoClient(server, username, password string) driver.Client {
 	conn, err := http.NewConnection(http.ConnectionConfig{
 		Endpoints: []string{server},
 	})
-	fmt.Println("Connecting to the database:", server)
+	fmt.Fprintln(logOutput, "Connecting to the database:", server)
 	if err != nil {
-		fmt.Println("Error connecting the the database:", err.Error())
+		fmt.Fprintln(logOutput, "Error connecting the the database:", err.Error())
 		panic(err.Error())
 	}
 	conn, err = conn.SetAuthentication(driver.BasicAuthentication(username, password))
 	if err != nil {
-		fmt.Println("Error setting the connection authentication:", err.Error())
+		fmt.Fprintln(logOutput, "Error setting the connection authentication:", err.Error())
 		panic(err.Error())
 	}
 	c, err := driver.NewClient(driver.ClientConfig{
 		Connection: conn,
 	})
 	if err != nil {
-		fmt.Println("Error creating the database client:", err.Error())
+		fmt.Fprintln(logOutput, "Error creating the database client:", err.Error())
 		panic(err.Error())
 	}
+	return c
+}
 
-	fmt.Println("Choosing the database:", dbname)
-	db, err := c.Database(nil, dbname)
+func createItem(c driver.Collection, item interface{}) {
+	meta, err := c.CreateDocument(nil, item)
 	if err != nil {
-		fmt.Println("Error choosing the database:", err.Error())
+		fmt.Fprintf(logOutput, "Error creating item: %s\nItem: %+v\n", err.Error(), item)
 		panic(err.Error())
 	}
-
-	return db, err
+	fmt.Fprintln(logOutput, "Created item. Meta:", meta)
 }
 
-func createItem(c driver.Collection, item interface{}) {
-	meta, err := c.CreateDocument(nil, item)
+// createSearchItem creates a document of a collection of the search view, with the text fields of its language
+func createSearchItem(c driver.Collection, item interface{}) {
+	doc, err := searchDocument(item)
 	if err != nil {
-		fmt.Printf("Error creating item: %s\nItem: %+v\n", err.Error(), item)
+		fmt.Fprintf(logOutput, "Error creating item: %s\nItem: %+v\n", err.Error(), item)
 		panic(err.Error())
 	}
-	fmt.Println("Created item. Meta:", meta)
+	createItem(c, doc)
 }
 
-func updateItem(c driver.Collection, key string, item interface{}) {
-	meta, err := c.UpdateDocument(nil, key, item)
+// writeGraph replaces the contents of the graph's collections with the given graph
+func writeGraph(db driver.Database, g *Graph) {
+	// A database created before the ratings and users would otherwise be truncated, then fail half way
+	if err := checkCollections(db); err != nil {
+		fmt.Fprintln(logOutput, "Error opening the collections:", err.Error())
+		panic(err.Error())
+	}
+
+	// Open collections for vertices
+	colClaims := openCollection(db, "claims", true)
+	colArgs := openCollection(db, "arguments", true)
+
+	// Open collections for edges
+	edgeInferences := openCollection(db, "inferences", true)
+	edgeBaseClaims := openCollection(db, "base_claims", true)
+	edgePremises := openCollection(db, "premises", true)
+
+	// Open collections for ratings
+	colRatings := openCollection(db, "ratings", true)
+	edgeRatingTargets := openCollection(db, "rating_targets", true)
+
+	// Open collections for users
+	colUsers := openCollection(db, "users", true)
+	edgeCreated := openCollection(db, "created", true)
+
+	indexes, err := ensureIndexes(db)
 	if err != nil {
-		fmt.Printf("Error updating item: %s\nItem: %+v\n", err.Error(), item)
+		fmt.Fprintln(logOutput, "Error creating the indexes:", err.Error())
+		panic(err.Error())
+	}
+	views, err := ensureSearchView(db)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error creating the search view:", err.Error())
 		panic(err.Error())
 	}
-	fmt.Println("Updated item. Meta:", meta)
-}
 
-func createInference(c driver.Collection, fromid string, toArg Argument) {
-	inference := Inference{
-		Key:       uuid.New().String(),
-		CreatedAt: toArg.CreatedAt,
-		Creator:   toArg.Creator,
-		From:      fromid,
-		To:        toArg.ArangoID(),
-	}
-	createItem(c, inference)
-}
+	for _, claim := range g.Claims {
+		createSearchItem(colClaims, claim)
+	}
+	for _, arg := range g.Arguments {
+		createSearchItem(colArgs, arg)
+	}
+	for _, inference := range g.Inferences {
+		createItem(edgeInferences, inference)
+	}
+	for _, bc := range g.BaseClaims {
+		createItem(edgeBaseClaims, bc)
+	}
+	for _, premise := range g.Premises {
+		createItem(edgePremises, premise)
+	}
+	for _, rating := range g.Ratings {
+		createItem(colRatings, rating)
+	}
+	for _, edge := range g.RatingTargets {
+		createItem(edgeRatingTargets, edge)
+	}
+	for _, user := range g.Users {
+		createItem(colUsers, user)
+	}
+	for _, creation := range g.Creations {
+		createItem(edgeCreated, creation)
+	}
 
-func createPremise(c driver.Collection, fromid string, toClaim Claim, order int) {
-	premise := Premise{
-		Key:       uuid.New().String(),
-		CreatedAt: toClaim.CreatedAt,
-		Creator:   toClaim.Creator,
-		From:      fromid,
-		To:        toClaim.ArangoID(),
-		Order:     order,
-	}
-	createItem(c, premise)
+	fmt.Fprintf(logOutput, "Wrote %d claims, %d arguments, %d inferences, %d base claims, %d premises, %d ratings and %d users\n",
+		len(g.Claims), len(g.Arguments), len(g.Inferences), len(g.BaseClaims), len(g.Premises), len(g.Ratings), len(g.Users))
+	for _, line := range indexes {
+		fmt.Fprintln(logOutput, "Index:", line)
+	}
+	for _, line := range views {
+		fmt.Fprintln(logOutput, "View:", line)
+	}
 }
 
-func createBaseClaim(c driver.Collection, fromArg Argument, toid string) {
-	bc := BaseClaim{
-		Key:       uuid.New().String(),
-		CreatedAt: fromArg.CreatedAt,
-		Creator:   fromArg.Creator,
-		From:      fromArg.ArangoID(),
-		To:        toid,
-	}
-	createItem(c, bc)
+// checkCollections makes sure the database has every collection of the graph, before anything is truncated
+func checkCollections(db driver.Database) error {
+	missing := []string{}
+	for _, collection := range schemaCollections {
+		exists, err := db.CollectionExists(nil, collection.Name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = append(missing, collection.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the database has no %s collection; create them with `schema apply`, or import with --ensure-schema",
+			strings.Join(missing, ", "))
+	}
+	return nil
 }
 
 func openCollection(db driver.Database, name string, truncate bool) driver.Collection {
 	col, err := db.Collection(nil, name)
 	if err != nil {
-		fmt.Printf("Error opening %s collection: %s\n", name, err.Error())
+		fmt.Fprintf(logOutput, "Error opening %s collection: %s\n", name, err.Error())
 		panic(err.Error())
 	}
 	if truncate {
 		err = col.Truncate(nil)
 		if err != nil {
-			fmt.Printf("Error truncating %s: %s", name, err.Error())
+			fmt.Fprintf(logOutput, "Error truncating %s: %s", name, err.Error())
 			panic(err.Error())
 		}
 	}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// collectionsDB is a database that only has collections of documents, which it returns from any query
+type collectionsDB struct {
+	driver.Database
+	collections map[string][]string
+}
+
+func (db collectionsDB) CollectionExists(ctx context.Context, name string) (bool, error) {
+	_, ok := db.collections[name]
+	return ok, nil
+}
+
+func (db collectionsDB) Query(ctx context.Context, query string, bindVars map[string]interface{}) (driver.Cursor, error) {
+	name, _ := bindVars["@col"].(string)
+	docs, ok := db.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("collection or view not found: %s", name)
+	}
+	return &documentsCursor{docs: docs}, nil
+}
+
+type documentsCursor struct {
+	driver.Cursor
+	docs []string
+}
+
+func (c *documentsCursor) HasMore() bool { return len(c.docs) > 0 }
+func (c *documentsCursor) Close() error  { return nil }
+
+func (c *documentsCursor) ReadDocument(ctx context.Context, result interface{}) (driver.DocumentMeta, error) {
+	doc := c.docs[0]
+	c.docs = c.docs[1:]
+	return driver.DocumentMeta{}, json.Unmarshal([]byte(doc), result)
+}
+
+func TestCheckCollections(t *testing.T) {
+	db := collectionsDB{collections: map[string][]string{}}
+	for _, collection := range schemaCollections {
+		db.collections[collection.Name] = nil
+	}
+	if err := checkCollections(db); err != nil {
+		t.Fatalf("checkCollections: %s", err.Error())
+	}
+
+	// A database created before the ratings and users
+	for _, name := range []string{"ratings", "rating_targets", "users", "created"} {
+		delete(db.collections, name)
+	}
+	err := checkCollections(db)
+	if err == nil {
+		t.Fatalf("checkCollections accepted a database without ratings and users")
+	}
+	for _, expected := range []string{"ratings, rating_targets, users, created", "schema apply", "--ensure-schema"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("the error %q doesn't mention %q", err.Error(), expected)
+		}
+	}
+}
+
+func TestOpenOutputStdout(t *testing.T) {
+	stdout := os.Stdout
+	defer func() { logOutput = os.Stdout }()
+	// The data goes to the standard output, the progress messages to the standard error
+	if out := openOutput("-"); out != stdout || os.Stdout != stdout || logOutput != os.Stderr {
+		t.Errorf("openOutput(-) returned %v, with os.Stdout %v and the progress on %v", out.Name(), os.Stdout.Name(), logOutput)
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const NEO4J_LABEL_CLAIM = "Claim"
+const NEO4J_LABEL_ARGUMENT = "Argument"
+const NEO4J_LABEL_RATING = "Rating"
+const NEO4J_LABEL_USER = "User"
+const NEO4J_TYPE_INFERENCE = "INFERENCE"
+const NEO4J_TYPE_BASE_CLAIM = "BASE_CLAIM"
+const NEO4J_TYPE_PREMISE = "PREMISE"
+const NEO4J_TYPE_RATING_TARGET = "RATING_TARGET"
+const NEO4J_TYPE_CREATED = "CREATED"
+
+// Property types, as named in the headers of the neo4j-admin import files
+const NEO4J_STRING = "string"
+const NEO4J_INT = "int"
+const NEO4J_FLOAT = "float"
+const NEO4J_BOOLEAN = "boolean"
+const NEO4J_DATETIME = "datetime"
+const NEO4J_STRING_ARRAY = "string[]"
+
+// Separator of the values of an array in the import files (the default of neo4j-admin)
+const NEO4J_ARRAY_DELIMITER = ";"
+
+// A neo4jProperty is copied from the field with the same JSON name in the ArangoDB document.
+// The _key of every node and relationship is stored as "key", which is what the MERGE statements match on.
+type neo4jProperty struct {
+	Name string
+	Type string
+}
+
+var neo4jClaimProperties = []neo4jProperty{
+	{"id", NEO4J_STRING},
+	{"start", NEO4J_DATETIME},
+	{"creator", NEO4J_STRING},
+	{"title", NEO4J_STRING},
+	{"negation", NEO4J_STRING},
+	{"question", NEO4J_STRING},
+	{"note", NEO4J_STRING},
+	{"mp", NEO4J_BOOLEAN},
+	{"mprule", NEO4J_INT},
+	{"truth", NEO4J_FLOAT},
+	{"terms", NEO4J_STRING_ARRAY},
+	{"lang", NEO4J_STRING},
+}
+
+var neo4jArgumentProperties = []neo4jProperty{
+	{"id", NEO4J_STRING},
+	{"start", NEO4J_DATETIME},
+	{"creator", NEO4J_STRING},
+	{"targetClaimId", NEO4J_STRING},
+	{"targetArgId", NEO4J_STRING},
+	{"claimId", NEO4J_STRING},
+	{"title", NEO4J_STRING},
+	{"negation", NEO4J_STRING},
+	{"question", NEO4J_STRING},
+	{"note", NEO4J_STRING},
+	{"pro", NEO4J_BOOLEAN},
+	{"relevance", NEO4J_FLOAT},
+	{"strength", NEO4J_FLOAT},
+	{"terms", NEO4J_STRING_ARRAY},
+	{"lang", NEO4J_STRING},
+}
+
+var neo4jRatingProperties = []neo4jProperty{
+	{"node", NEO4J_STRING},
+	{"start", NEO4J_DATETIME},
+	{"creator", NEO4J_STRING},
+	{"type", NEO4J_STRING},
+	{"value", NEO4J_FLOAT},
+}
+
+var neo4jUserProperties = []neo4jProperty{
+	{"id", NEO4J_STRING},
+	{"start", NEO4J_DATETIME},
+	{"name", NEO4J_STRING},
+	{"account", NEO4J_STRING},
+}
+
+var neo4jEdgeProperties = []neo4jProperty{
+	{"start", NEO4J_DATETIME},
+	{"creator", NEO4J_STRING},
+}
+
+var neo4jPremiseProperties = append(append([]neo4jProperty{}, neo4jEdgeProperties...), neo4jProperty{"order", NEO4J_INT})
+
+// Labels of the nodes, by ArangoDB collection
+var neo4jLabels = map[string]string{
+	"claims":    NEO4J_LABEL_CLAIM,
+	"arguments": NEO4J_LABEL_ARGUMENT,
+	"ratings":   NEO4J_LABEL_RATING,
+	"users":     NEO4J_LABEL_USER,
+}
+
+// A neo4jItem is a node or relationship, with the JSON fields of its ArangoDB document
+type neo4jItem struct {
+	Key    string
+	Label  string
+	From   string
+	To     string
+	Fields map[string]interface{}
+}
+
+func newNeo4jItem(label string, doc interface{}) (neo4jItem, error) {
+	item := neo4jItem{Label: label}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return item, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&item.Fields); err != nil {
+		return item, err
+	}
+	item.Key, _ = item.Fields["_key"].(string)
+	item.From, _ = item.Fields["_from"].(string)
+	item.To, _ = item.Fields["_to"].(string)
+	return item, nil
+}
+
+// neo4jNodes and neo4jRelationships list the graph in the order the ArangoDB import writes it
+func neo4jNodes(g *Graph) ([]neo4jItem, error) {
+	items := []neo4jItem{}
+	for _, claim := range g.Claims {
+		item, err := newNeo4jItem(NEO4J_LABEL_CLAIM, claim)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	for _, arg := range g.Arguments {
+		item, err := newNeo4jItem(NEO4J_LABEL_ARGUMENT, arg)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	for _, rating := range g.Ratings {
+		item, err := newNeo4jItem(NEO4J_LABEL_RATING, rating)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	for _, user := range g.Users {
+		item, err := newNeo4jItem(NEO4J_LABEL_USER, user)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func neo4jRelationships(g *Graph) ([]neo4jItem, error) {
+	items := []neo4jItem{}
+	add := func(label string, doc interface{}) error {
+		item, err := newNeo4jItem(label, doc)
+		if err == nil {
+			items = append(items, item)
+		}
+		return err
+	}
+	for _, inference := range g.Inferences {
+		if err := add(NEO4J_TYPE_INFERENCE, inference); err != nil {
+			return nil, err
+		}
+	}
+	for _, bc := range g.BaseClaims {
+		if err := add(NEO4J_TYPE_BASE_CLAIM, bc); err != nil {
+			return nil, err
+		}
+	}
+	for _, premise := range g.Premises {
+		if err := add(NEO4J_TYPE_PREMISE, premise); err != nil {
+			return nil, err
+		}
+	}
+	for _, edge := range g.RatingTargets {
+		if err := add(NEO4J_TYPE_RATING_TARGET, edge); err != nil {
+			return nil, err
+		}
+	}
+	for _, creation := range g.Creations {
+		if err := add(NEO4J_TYPE_CREATED, creation); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func neo4jProperties(label string) []neo4jProperty {
+	switch label {
+	case NEO4J_LABEL_CLAIM:
+		return neo4jClaimProperties
+	case NEO4J_LABEL_ARGUMENT:
+		return neo4jArgumentProperties
+	case NEO4J_LABEL_RATING:
+		return neo4jRatingProperties
+	case NEO4J_LABEL_USER:
+		return neo4jUserProperties
+	case NEO4J_TYPE_PREMISE:
+		return neo4jPremiseProperties
+	default:
+		return neo4jEdgeProperties
+	}
+}
+
+// neo4jEndpoint splits a document handle (claims/<key>) into the label and key of the node
+func neo4jEndpoint(handle string) (string, string, error) {
+	parts := strings.SplitN(handle, "/", 2)
+	if len(parts) != 2 || neo4jLabels[parts[0]] == "" {
+		return "", "", fmt.Errorf("unexpected edge end %q", handle)
+	}
+	return neo4jLabels[parts[0]], parts[1], nil
+}
+
+// neo4jText formats a field for the import files. Missing fields (such as an unset targetClaimId) are empty.
+func neo4jText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			values = append(values, neo4jText(item))
+		}
+		return strings.Join(values, NEO4J_ARRAY_DELIMITER)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// cypherString quotes a string for Cypher, whose escapes in double-quoted strings are the same as JSON's
+func cypherString(s string) string {
+	b := &bytes.Buffer{}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func cypherValue(property neo4jProperty, value interface{}) string {
+	switch property.Type {
+	case NEO4J_STRING:
+		return cypherString(neo4jText(value))
+	case NEO4J_DATETIME:
+		return "datetime(" + cypherString(neo4jText(value)) + ")"
+	case NEO4J_STRING_ARRAY:
+		items, _ := value.([]interface{})
+		values := []string{}
+		for _, item := range items {
+			values = append(values, cypherString(neo4jText(item)))
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	default:
+		return neo4jText(value)
+	}
+}
+
+func cypherSet(variable string, item neo4jItem) string {
+	sets := []string{}
+	for _, property := range neo4jProperties(item.Label) {
+		value, ok := item.Fields[property.Name]
+		if !ok || value == nil {
+			continue
+		}
+		sets = append(sets, fmt.Sprintf("%s.%s = %s", variable, property.Name, cypherValue(property, value)))
+	}
+	if len(sets) == 0 {
+		return ""
+	}
+	return " SET " + strings.Join(sets, ", ")
+}
+
+// writeCypher writes the graph as Cypher MERGE statements, for cypher-shell:
+//
+//	cat debate.cypher | cypher-shell -u neo4j -p password
+//
+// Nodes and relationships are matched on their key, so running the statements again updates the graph in place.
+func writeCypher(w io.Writer, g *Graph) error {
+	nodes, err := neo4jNodes(g)
+	if err != nil {
+		return err
+	}
+	relationships, err := neo4jRelationships(g)
+	if err != nil {
+		return err
+	}
+
+	b := &bytes.Buffer{}
+	for _, label := range []string{NEO4J_LABEL_CLAIM, NEO4J_LABEL_ARGUMENT, NEO4J_LABEL_RATING, NEO4J_LABEL_USER} {
+		fmt.Fprintf(b, "CREATE CONSTRAINT %s_key IF NOT EXISTS FOR (n:%s) REQUIRE n.key IS UNIQUE;\n", strings.ToLower(label), label)
+	}
+	for _, node := range nodes {
+		fmt.Fprintf(b, "MERGE (n:%s {key: %s})%s;\n", node.Label, cypherString(node.Key), cypherSet("n", node))
+	}
+	for _, rel := range relationships {
+		fromLabel, fromKey, err := neo4jEndpoint(rel.From)
+		if err != nil {
+			return err
+		}
+		toLabel, toKey, err := neo4jEndpoint(rel.To)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "MATCH (a:%s {key: %s}), (b:%s {key: %s}) MERGE (a)-[r:%s {key: %s}]->(b)%s;\n",
+			fromLabel, cypherString(fromKey), toLabel, cypherString(toKey), rel.Label, cypherString(rel.Key), cypherSet("r", rel))
+	}
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// writeNeo4jCSV writes the graph as the CSV files of neo4j-admin database import, one per label and relationship type:
+//
+//	neo4j-admin database import full --nodes=Claim=claims.csv --nodes=Argument=arguments.csv \
+//	    --nodes=Rating=ratings.csv --nodes=User=users.csv --relationships=INFERENCE=inferences.csv \
+//	    --relationships=BASE_CLAIM=base_claims.csv --relationships=PREMISE=premises.csv \
+//	    --relationships=RATING_TARGET=rating_targets.csv --relationships=CREATED=created.csv
+//
+// Nodes are identified by their key, which is unique across claims, arguments, ratings and users.
+func writeNeo4jCSV(dir string, g *Graph) error {
+	nodes, err := neo4jNodes(g)
+	if err != nil {
+		return err
+	}
+	relationships, err := neo4jRelationships(g)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files := []struct {
+		Name  string
+		Label string
+		Edge  bool
+	}{
+		{"claims.csv", NEO4J_LABEL_CLAIM, false},
+		{"arguments.csv", NEO4J_LABEL_ARGUMENT, false},
+		{"ratings.csv", NEO4J_LABEL_RATING, false},
+		{"users.csv", NEO4J_LABEL_USER, false},
+		{"inferences.csv", NEO4J_TYPE_INFERENCE, true},
+		{"base_claims.csv", NEO4J_TYPE_BASE_CLAIM, true},
+		{"premises.csv", NEO4J_TYPE_PREMISE, true},
+		{"rating_targets.csv", NEO4J_TYPE_RATING_TARGET, true},
+		{"created.csv", NEO4J_TYPE_CREATED, true},
+	}
+	for _, file := range files {
+		properties := neo4jProperties(file.Label)
+		header := []string{"key:ID"}
+		if file.Edge {
+			header = []string{":START_ID", ":END_ID", "key"}
+		}
+		for _, property := range properties {
+			if property.Type == NEO4J_STRING {
+				header = append(header, property.Name)
+			} else {
+				header = append(header, property.Name+":"+property.Type)
+			}
+		}
+		records := [][]string{header}
+
+		items := nodes
+		if file.Edge {
+			items = relationships
+		}
+		for _, item := range items {
+			if item.Label != file.Label {
+				continue
+			}
+			record := []string{item.Key}
+			if file.Edge {
+				_, fromKey, err := neo4jEndpoint(item.From)
+				if err != nil {
+					return err
+				}
+				_, toKey, err := neo4jEndpoint(item.To)
+				if err != nil {
+					return err
+				}
+				record = []string{fromKey, toKey, item.Key}
+			}
+			for _, property := range properties {
+				record = append(record, neo4jText(item.Fields[property.Name]))
+			}
+			records = append(records, record)
+		}
+
+		out, err := os.Create(filepath.Join(dir, file.Name))
+		if err != nil {
+			return err
+		}
+		writer := csv.NewWriter(out)
+		writer.WriteAll(records)
+		if err := writer.Error(); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(logOutput, "Wrote %d rows to %s\n", len(records)-1, filepath.Join(dir, file.Name))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriteCypher(t *testing.T) {
+	g := testGraph(t)
+	b := &bytes.Buffer{}
+	if err := writeCypher(b, g); err != nil {
+		t.Fatalf("writeCypher: %s", err.Error())
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+
+	counts := map[string]int{}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, ";") {
+			t.Errorf("statement not terminated: %s", line)
+		}
+		counts[strings.SplitN(line, " ", 2)[0]]++
+	}
+	nodes := len(g.Claims) + len(g.Arguments) + len(g.Ratings) + len(g.Users)
+	relationships := len(g.Inferences) + len(g.BaseClaims) + len(g.Premises) + len(g.RatingTargets) + len(g.Creations)
+	if counts["CREATE"] != 4 || counts["MERGE"] != nodes || counts["MATCH"] != relationships || len(lines) != 4+nodes+relationships {
+		t.Errorf("wrote the statements %v, expected 4 CREATE, %d MERGE and %d MATCH", counts, nodes, relationships)
+	}
+
+	claims := map[string]Claim{}
+	for _, claim := range g.Claims {
+		claims[claim.ID] = claim
+	}
+	expected := []string{
+		"CREATE CONSTRAINT claim_key IF NOT EXISTS FOR (n:Claim) REQUIRE n.key IS UNIQUE;",
+		`MERGE (n:Claim {key: "` + claims["P2"].Key + `"}) SET n.id = "P2", n.start = datetime("2019-03-11T19:18:20Z"), n.creator = "u2", ` +
+			`n.title = "The \"levelized\" cost of nuclear power keeps falling every single year."`,
+	}
+	for _, statement := range expected {
+		if !strings.Contains(b.String(), statement) {
+			t.Errorf("missing statement: %s", statement)
+		}
+	}
+	for _, premise := range g.Premises {
+		if !strings.Contains(b.String(), `MERGE (a)-[r:PREMISE {key: "`+premise.Key+`"}]->(b)`) || !strings.Contains(b.String(), "r.order = ") {
+			t.Errorf("missing premise %s", premise.Key)
+		}
+	}
+}
+
+func readNeo4jCSV(t *testing.T, dir, name string) [][]string {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("opening %s: %s", name, err.Error())
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("reading %s: %s", name, err.Error())
+	}
+	return records
+}
+
+func TestWriteNeo4jCSV(t *testing.T) {
+	g := testGraph(t)
+	dir := filepath.Join(t.TempDir(), "neo4j-import")
+	if err := writeNeo4jCSV(dir, g); err != nil {
+		t.Fatalf("writeNeo4jCSV: %s", err.Error())
+	}
+
+	// Every node file starts with its ID, and every relationship file with the IDs of its ends
+	ids := map[string]bool{}
+	nodeFiles := map[string]int{"claims.csv": len(g.Claims), "arguments.csv": len(g.Arguments), "ratings.csv": len(g.Ratings), "users.csv": len(g.Users)}
+	for name, count := range nodeFiles {
+		records := readNeo4jCSV(t, dir, name)
+		if records[0][0] != "key:ID" || len(records) != count+1 {
+			t.Errorf("%s has the header %v and %d rows, expected key:ID and %d rows", name, records[0], len(records)-1, count)
+		}
+		for _, record := range records[1:] {
+			if ids[record[0]] {
+				t.Errorf("the node ID %s is not unique", record[0])
+			}
+			ids[record[0]] = true
+		}
+	}
+	edgeFiles := map[string]int{"inferences.csv": len(g.Inferences), "base_claims.csv": len(g.BaseClaims), "premises.csv": len(g.Premises),
+		"rating_targets.csv": len(g.RatingTargets), "created.csv": len(g.Creations)}
+	for name, count := range edgeFiles {
+		records := readNeo4jCSV(t, dir, name)
+		if strings.Join(records[0][:3], ",") != ":START_ID,:END_ID,key" || len(records) != count+1 {
+			t.Errorf("%s has the header %v and %d rows, expected :START_ID,:END_ID,key and %d rows", name, records[0], len(records)-1, count)
+		}
+		for _, record := range records[1:] {
+			if !ids[record[0]] || !ids[record[1]] {
+				t.Errorf("%s links %s to %s, which are not both nodes", name, record[0], record[1])
+			}
+		}
+	}
+
+	// The typed columns, and a title with quotes, are read back as they were written
+	columns := func(header []string) map[string]int {
+		m := map[string]int{}
+		for i, name := range header {
+			m[name] = i
+		}
+		return m
+	}
+	claims := readNeo4jCSV(t, dir, "claims.csv")
+	claimColumns := columns(claims[0])
+	titles := map[string]string{}
+	for _, record := range claims[1:] {
+		titles[record[claimColumns["id"]]] = record[claimColumns["title"]]
+		if record[claimColumns["truth:float"]] == "" || record[claimColumns["start:datetime"]] == "" {
+			t.Errorf("claim %s has no truth or start: %v", record[claimColumns["id"]], record)
+		}
+	}
+	if titles["P2"] != `The "levelized" cost of nuclear power keeps falling every single year.` {
+		t.Errorf("read the title of P2 as %q", titles["P2"])
+	}
+	premises := readNeo4jCSV(t, dir, "premises.csv")
+	orders := []string{}
+	for _, record := range premises[1:] {
+		orders = append(orders, record[columns(premises[0])["order:int"]])
+	}
+	sort.Strings(orders)
+	if strings.Join(orders, ",") != "1,2" {
+		t.Errorf("wrote the premise orders %v, expected 1,2", orders)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Attributes written for the vertices and edges by the network analysis exporters (GraphML and GEXF)
+type networkAttribute struct {
+	Name string
+	// Type is the GraphML type; GEXF uses the same names, except for "int" (see gexfType)
+	Type string
+}
+
+var networkVertexAttributes = []networkAttribute{
+	{"kind", "string"},
+	{"id", "string"},
+	{"title", "string"},
+	{"negation", "string"},
+	{"question", "string"},
+	{"note", "string"},
+	{"creator", "string"},
+	{"start", "string"},
+	{"truth", "double"},
+	{"mp", "boolean"},
+	{"mprule", "int"},
+	{"pro", "boolean"},
+	{"relevance", "double"},
+	{"strength", "double"},
+	{"lang", "string"},
+}
+
+var networkEdgeAttributes = []networkAttribute{
+	{"type", "string"},
+	{"order", "int"},
+	{"creator", "string"},
+	{"start", "string"},
+}
+
+// A networkElement is a vertex or edge of the graph, with its attribute values
+type networkElement struct {
+	ID     string
+	Label  string
+	Source string
+	Target string
+	Values map[string]string
+}
+
+func networkTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func networkFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
+
+// networkVertices lists the Claims and Arguments, identified by their document handles
+func networkVertices(g *Graph) []networkElement {
+	vertices := []networkElement{}
+	for _, claim := range g.Claims {
+		vertices = append(vertices, networkElement{
+			ID:    claim.ArangoID(),
+			Label: claim.Title,
+			Values: map[string]string{
+				"kind":     "claim",
+				"id":       claim.ID,
+				"title":    claim.Title,
+				"negation": claim.Negation,
+				"question": claim.Question,
+				"note":     claim.Note,
+				"creator":  claim.Creator,
+				"start":    networkTime(claim.CreatedAt),
+				"truth":    networkFloat(claim.Truth),
+				"mp":       strconv.FormatBool(claim.MultiPremise),
+				"mprule":   strconv.Itoa(claim.PremiseRule),
+				"lang":     claim.Language,
+			},
+		})
+	}
+	for _, arg := range g.Arguments {
+		vertices = append(vertices, networkElement{
+			ID:    arg.ArangoID(),
+			Label: arg.Title,
+			Values: map[string]string{
+				"kind":      "argument",
+				"id":        arg.ID,
+				"title":     arg.Title,
+				"negation":  arg.Negation,
+				"question":  arg.Question,
+				"note":      arg.Note,
+				"creator":   arg.Creator,
+				"start":     networkTime(arg.CreatedAt),
+				"pro":       strconv.FormatBool(arg.Pro),
+				"relevance": networkFloat(arg.Relevance),
+				"strength":  networkFloat(arg.Str),
+				"lang":      arg.Language,
+			},
+		})
+	}
+	return vertices
+}
+
+// networkEdges lists the inferences, base claims and premises, skipping any edge whose ends are not in the graph
+func networkEdges(g *Graph) []networkElement {
+	vertices := map[string]bool{}
+	for _, claim := range g.Claims {
+		vertices[claim.ArangoID()] = true
+	}
+	for _, arg := range g.Arguments {
+		vertices[arg.ArangoID()] = true
+	}
+
+	edges := []networkElement{}
+	add := func(collection, key, from, to string, values map[string]string) {
+		if !vertices[from] || !vertices[to] {
+			fmt.Fprintf(logOutput, "----------------------------Skipping %s edge %s: %s -> %s not found\n", collection, key, from, to)
+			return
+		}
+		edges = append(edges, networkElement{
+			ID:     collection + "/" + key,
+			Label:  values["type"],
+			Source: from,
+			Target: to,
+			Values: values,
+		})
+	}
+	for _, inference := range g.Inferences {
+		add("inferences", inference.Key, inference.From, inference.To, map[string]string{
+			"type":    "inference",
+			"creator": inference.Creator,
+			"start":   networkTime(inference.CreatedAt),
+		})
+	}
+	for _, bc := range g.BaseClaims {
+		add("base_claims", bc.Key, bc.From, bc.To, map[string]string{
+			"type":    "base_claim",
+			"creator": bc.Creator,
+			"start":   networkTime(bc.CreatedAt),
+		})
+	}
+	for _, premise := range g.Premises {
+		add("premises", premise.Key, premise.From, premise.To, map[string]string{
+			"type":    "premise",
+			"order":   strconv.Itoa(premise.Order),
+			"creator": premise.Creator,
+			"start":   networkTime(premise.CreatedAt),
+		})
+	}
+	return edges
+}
+
+// GraphML (http://graphml.graphdrawing.org), as read by networkx, Gephi, yEd...
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string           `xml:"id,attr"`
+	EdgeDefault string           `xml:"edgedefault,attr"`
+	Nodes       []graphMLElement `xml:"node"`
+	Edges       []graphMLElement `xml:"edge"`
+}
+
+type graphMLElement struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeGraphML(w io.Writer, g *Graph) error {
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: "debate_map", EdgeDefault: "directed"},
+	}
+	for _, attr := range networkVertexAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "v_" + attr.Name, For: "node", AttrName: attr.Name, AttrType: attr.Type})
+	}
+	for _, attr := range networkEdgeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "e_" + attr.Name, For: "edge", AttrName: attr.Name, AttrType: attr.Type})
+	}
+	toGraphML := func(e networkElement, prefix string, attrs []networkAttribute) graphMLElement {
+		element := graphMLElement{ID: e.ID, Source: e.Source, Target: e.Target}
+		for _, attr := range attrs {
+			if value, ok := e.Values[attr.Name]; ok && value != "" {
+				element.Data = append(element.Data, graphMLData{Key: prefix + attr.Name, Value: value})
+			}
+		}
+		return element
+	}
+	for _, v := range networkVertices(g) {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, toGraphML(v, "v_", networkVertexAttributes))
+	}
+	for _, e := range networkEdges(g) {
+		doc.Graph.Edges = append(doc.Graph.Edges, toGraphML(e, "e_", networkEdgeAttributes))
+	}
+	return writeXML(w, doc)
+}
+
+// GEXF 1.2 (https://gexf.net), Gephi's native format
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfElement    `xml:"nodes>node"`
+	Edges           []gexfElement    `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfElement struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr,omitempty"`
+	Source    string         `xml:"source,attr,omitempty"`
+	Target    string         `xml:"target,attr,omitempty"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func gexfType(graphMLType string) string {
+	switch graphMLType {
+	case "int":
+		return "integer"
+	default:
+		return graphMLType
+	}
+}
+
+func writeGEXF(w io.Writer, g *Graph) error {
+	doc := gexfDocument{
+		Xmlns:   "http://www.gexf.net/1.2draft",
+		Version: "1.2",
+		Graph:   gexfGraph{DefaultEdgeType: "directed", Mode: "static"},
+	}
+	nodeAttributes := gexfAttributes{Class: "node"}
+	for _, attr := range networkVertexAttributes {
+		nodeAttributes.Attributes = append(nodeAttributes.Attributes, gexfAttribute{ID: attr.Name, Title: attr.Name, Type: gexfType(attr.Type)})
+	}
+	edgeAttributes := gexfAttributes{Class: "edge"}
+	for _, attr := range networkEdgeAttributes {
+		edgeAttributes.Attributes = append(edgeAttributes.Attributes, gexfAttribute{ID: attr.Name, Title: attr.Name, Type: gexfType(attr.Type)})
+	}
+	doc.Graph.Attributes = []gexfAttributes{nodeAttributes, edgeAttributes}
+
+	toGEXF := func(e networkElement, attrs []networkAttribute) gexfElement {
+		element := gexfElement{ID: e.ID, Label: e.Label, Source: e.Source, Target: e.Target}
+		for _, attr := range attrs {
+			if value, ok := e.Values[attr.Name]; ok && value != "" {
+				element.AttValues = append(element.AttValues, gexfAttValue{For: attr.Name, Value: value})
+			}
+		}
+		return element
+	}
+	for _, v := range networkVertices(g) {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, toGEXF(v, networkVertexAttributes))
+	}
+	for _, e := range networkEdges(g) {
+		doc.Graph.Edges = append(doc.Graph.Edges, toGEXF(e, networkEdgeAttributes))
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -14,3 +14,14 @@ type Premise struct {
 	To        string    `json:"_to,omitempty"`
 	Order     int       `json:"order"`
 }
+
+func NewPremise(fromid string, toClaim Claim, order int) Premise {
+	return Premise{
+		Key:       edgeKey("premises", fromid, toClaim.ArangoID()),
+		CreatedAt: toClaim.CreatedAt,
+		Creator:   toClaim.Creator,
+		From:      fromid,
+		To:        toClaim.ArangoID(),
+		Order:     order,
+	}
+}
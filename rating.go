@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Debate Map rates from 0 to 100; the ratings collection stores the value from 0 to 1, like the scores
+const RATING_SCALE = 100.0
+
+// The score field each type of Debate Map rating initializes, by rating type.
+// Older versions rated the "truth" of claims and the "impact" of arguments; other types
+// (significance, neutrality...) are kept in the ratings collection, but initialize nothing.
+var ratingScores = map[string]string{
+	"truth":       "truth",
+	"probability": "truth",
+	"relevance":   "relevance",
+	"impact":      "relevance",
+	"strength":    "strength",
+}
+
+// NodeRatings are the ratings of one node, by rating type and user:
+//
+//	{"_key": <node>, "probability": {"_key": "probability", <user>: {"updated": 1551183882923, "value": 85}}, ...}
+type NodeRatings struct {
+	ID      string
+	Ratings []NodeRating
+}
+
+type NodeRating struct {
+	Type    string  `json:"-"`
+	User    string  `json:"_key"`
+	Updated int64   `json:"updated"`
+	Value   float64 `json:"value"`
+}
+
+func (nr *NodeRatings) UnmarshalJSON(data []byte) error {
+	var types map[string]json.RawMessage
+	if err := json.Unmarshal(data, &types); err != nil {
+		return err
+	}
+	*nr = NodeRatings{}
+	for ratingType, raw := range types {
+		if ratingType == "_key" {
+			if err := json.Unmarshal(raw, &nr.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		var users map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &users); err != nil {
+			return fmt.Errorf("%s ratings: %s", ratingType, err.Error())
+		}
+		for user, rawRating := range users {
+			if user == "_key" {
+				continue
+			}
+			rating := NodeRating{}
+			if err := json.Unmarshal(rawRating, &rating); err != nil {
+				return fmt.Errorf("%s rating of %s: %s", ratingType, user, err.Error())
+			}
+			rating.Type = ratingType
+			if rating.User == "" {
+				rating.User = user
+			}
+			nr.Ratings = append(nr.Ratings, rating)
+		}
+	}
+	sort.Slice(nr.Ratings, func(i, j int) bool {
+		if nr.Ratings[i].Type != nr.Ratings[j].Type {
+			return nr.Ratings[i].Type < nr.Ratings[j].Type
+		}
+		return nr.Ratings[i].User < nr.Ratings[j].User
+	})
+	return nil
+}
+
+func (rating NodeRating) UpdatedTime() time.Time {
+	return time.Unix(0, rating.Updated*1000000)
+}
+
+// A Rating is the rating a user gave a Debate Map node
+type Rating struct {
+	Key       string    `json:"_key"`
+	Node      string    `json:"node"`
+	CreatedAt time.Time `json:"start"`
+	Creator   string    `json:"creator"`
+	Type      string    `json:"type"`
+	Value     float32   `json:"value"`
+}
+
+func (rating Rating) ArangoID() string {
+	return fmt.Sprintf("ratings/%s", rating.Key)
+}
+
+// ratingKey derives the _key of a rating from the node, the type of rating and the user who gave it
+func ratingKey(node, ratingType, user string) string {
+	return documentKey("ratings", node+"/"+ratingType+"/"+user)
+}
+
+func NewRating(node string, nodeRating NodeRating) Rating {
+	value := nodeRating.Value / RATING_SCALE
+	if value < 0 {
+		value = 0
+	} else if value > 1 {
+		value = 1
+	}
+	return Rating{
+		Key:       ratingKey(node, nodeRating.Type, nodeRating.User),
+		Node:      node,
+		CreatedAt: nodeRating.UpdatedTime(),
+		Creator:   nodeRating.User,
+		Type:      nodeRating.Type,
+		Value:     float32(value),
+	}
+}
+
+// A RatingTarget is an edge from a Rating to the Claim or Argument that was rated
+type RatingTarget struct {
+	Key       string    `json:"_key"`
+	CreatedAt time.Time `json:"start"`
+	Creator   string    `json:"creator"`
+	From      string    `json:"_from,omitempty"`
+	To        string    `json:"_to,omitempty"`
+}
+
+func NewRatingTarget(fromRating Rating, toid string) RatingTarget {
+	return RatingTarget{
+		Key:       edgeKey("rating_targets", fromRating.ArangoID(), toid),
+		CreatedAt: fromRating.CreatedAt,
+		Creator:   fromRating.Creator,
+		From:      fromRating.ArangoID(),
+		To:        toid,
+	}
+}
+
+// addRatings adds the ratings of the nodes to the graph, each with an edge to what was rated.
+// A node may have become several documents: the truth of an argument node is rated on its claim
+// (the multi-premise claim, or the claim a category was converted to), its relevance and strength on the argument.
+func addRatings(g *Graph, nodeRatings []NodeRatings) {
+	claims := map[string]string{}
+	for _, claim := range g.Claims {
+		claims[claim.ID] = claim.ArangoID()
+	}
+	args := map[string]string{}
+	for _, arg := range g.Arguments {
+		if _, ok := args[arg.ID]; !ok {
+			args[arg.ID] = arg.ArangoID()
+		}
+	}
+	target := func(node, ratingType string) string {
+		claim := claims[node]
+		for _, suffix := range []string{MP_CLAIM_ID_SUFFIX, CONVERTED_CLAIM_ID_SUFFIX} {
+			if claim == "" {
+				claim = claims[node+suffix]
+			}
+		}
+		arg := args[node]
+		if arg == "" || (ratingScores[ratingType] == "truth" && claim != "") {
+			return claim
+		}
+		return arg
+	}
+
+	for _, nr := range nodeRatings {
+		for _, nodeRating := range nr.Ratings {
+			to := target(nr.ID, nodeRating.Type)
+			if to == "" {
+				fmt.Fprintf(logOutput, "----------------------------Skipping %s rating of %s: node %s not found\n", nodeRating.Type, nodeRating.User, nr.ID)
+				continue
+			}
+			rating := NewRating(nr.ID, nodeRating)
+			g.Ratings = append(g.Ratings, rating)
+			g.RatingTargets = append(g.RatingTargets, NewRatingTarget(rating, to))
+		}
+	}
+	applyRatings(g)
+}
+
+// applyRatings sets the truth of the rated claims, and the relevance and strength of the rated arguments,
+// to the average of their ratings. The documents without ratings keep the scores they start with.
+func applyRatings(g *Graph) {
+	if len(g.Ratings) == 0 {
+		return
+	}
+	rated := averageRatings(g)
+	fmt.Fprintf(logOutput, "Scored %d claims and arguments from %d ratings\n", rated, len(g.Ratings))
+}
+
+// averageRatings sets the scores of the rated documents to the average of their ratings, and counts them
+func averageRatings(g *Graph) int {
+	ratings := map[string]Rating{}
+	for _, rating := range g.Ratings {
+		ratings[rating.ArangoID()] = rating
+	}
+	sums := map[string]map[string]float64{}
+	counts := map[string]map[string]int{}
+	for _, edge := range g.RatingTargets {
+		rating, ok := ratings[edge.From]
+		score := ratingScores[rating.Type]
+		if !ok || score == "" {
+			continue
+		}
+		if sums[edge.To] == nil {
+			sums[edge.To] = map[string]float64{}
+			counts[edge.To] = map[string]int{}
+		}
+		sums[edge.To][score] += float64(rating.Value)
+		counts[edge.To][score]++
+	}
+	average := func(handle, score string, value *float32) bool {
+		if counts[handle][score] == 0 {
+			return false
+		}
+		*value = float32(sums[handle][score] / float64(counts[handle][score]))
+		return true
+	}
+
+	rated := 0
+	for i, claim := range g.Claims {
+		if average(claim.ArangoID(), "truth", &g.Claims[i].Truth) {
+			rated++
+		}
+	}
+	for i, arg := range g.Arguments {
+		relevance := average(arg.ArangoID(), "relevance", &g.Arguments[i].Relevance)
+		strength := average(arg.ArangoID(), "strength", &g.Arguments[i].Str)
+		if relevance || strength {
+			rated++
+		}
+	}
+	return rated
+}
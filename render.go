@@ -0,0 +1,395 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const RENDER_VIEW_SOURCE = "source"
+const RENDER_VIEW_CONVERTED = "converted"
+const RENDER_VIEW_BOTH = "both"
+
+const DOT_COLOR_PRO = "darkgreen"
+const DOT_COLOR_CON = "red3"
+const DOT_COLOR_SYNTHESIZED = "gray45"
+
+// Longest line of a node label, before it wraps
+const DOT_LABEL_WIDTH = 32
+
+// runRender draws a subtree of the debate in Graphviz DOT, to check how the Debate Map structure was converted:
+//
+//	go run *.go render -f data/Test1.json --root Ikan0wFzSXm7GYSPvglJ3A --depth 3 | dot -Tsvg > tree.svg
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	source := GraphSourceFlags{}
+	source.Register(fs)
+	var rootID, view, output string
+	var depth int
+	fs.StringVar(&rootID, "root", "", "Debate Map node ID, claim/argument ID or _key to start from")
+	fs.IntVar(&depth, "depth", 3, "number of levels to draw below the root")
+	fs.StringVar(&view, "view", "", "structure to draw: source (Debate Map, needs -f), converted, or both side by side (default: both with -f, else converted)")
+	fs.StringVar(&output, "o", "-", "output file (\"-\" for stdout)")
+	fs.Parse(args)
+
+	if rootID == "" {
+		fmt.Fprintln(logOutput, "Error in --root: a node ID or _key is required")
+		panic("missing --root")
+	}
+	if view == "" {
+		view = RENDER_VIEW_CONVERTED
+		if source.Filename != "" {
+			view = RENDER_VIEW_BOTH
+		}
+	}
+	switch view {
+	case RENDER_VIEW_CONVERTED:
+	case RENDER_VIEW_SOURCE, RENDER_VIEW_BOTH:
+		if source.Filename == "" {
+			fmt.Fprintln(logOutput, "Error in --view: the Debate Map structure can only be drawn from an input file (-f)")
+			panic("--view " + view + " without -f")
+		}
+	default:
+		err := fmt.Errorf("unknown view %q (expected one of: %s, %s, %s)", view, RENDER_VIEW_SOURCE, RENDER_VIEW_CONVERTED, RENDER_VIEW_BOTH)
+		fmt.Fprintln(logOutput, "Error in --view:", err.Error())
+		panic(err.Error())
+	}
+
+	out := openOutput(output)
+	defer out.Close()
+
+	var root DebateMapRoot
+	var g *Graph
+	if source.Filename != "" {
+		root = source.LoadInput()
+		// buildGraph rewrites the nodes it converts, so it gets its own copy
+		converted := root
+		converted.Nodes = append([]DebateMapNode{}, root.Nodes...)
+		g = source.Convert(converted)
+	} else {
+		g = source.Load()
+	}
+
+	var err error
+	switch view {
+	case RENDER_VIEW_SOURCE:
+		err = writeDOT(out, []dotCluster{renderSource(root, g, rootID, depth)})
+	case RENDER_VIEW_CONVERTED:
+		err = writeDOT(out, []dotCluster{renderConverted(g, rootID, depth)})
+	case RENDER_VIEW_BOTH:
+		err = writeDOT(out, []dotCluster{renderSource(root, g, rootID, depth), renderConverted(g, rootID, depth)})
+	}
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error rendering the graph:", err.Error())
+		panic(err.Error())
+	}
+	fmt.Fprintln(logOutput, "Done.")
+}
+
+// A dotCluster is one of the structures drawn side by side
+type dotCluster struct {
+	Name  string
+	Label string
+	Nodes []dotNode
+	Edges []dotEdge
+}
+
+type dotNode struct {
+	ID          string
+	Label       string
+	Shape       string
+	Color       string
+	Synthesized bool
+}
+
+type dotEdge struct {
+	From  string
+	To    string
+	Label string
+	Color string
+}
+
+// renderSource draws the Debate Map nodes below the root, with the polarity and order found in their parents
+func renderSource(root DebateMapRoot, g *Graph, rootID string, depth int) dotCluster {
+	cluster := dotCluster{Name: "source", Label: "Debate Map"}
+	titles := map[string]string{}
+	for _, rev := range root.NodeRevisions {
+		titles[rev.ID] = rev.Title.Base
+	}
+	nodes := map[string]DebateMapNode{}
+	for _, node := range root.Nodes {
+		if node.ID == "" {
+			node.ID = node.Current.ID
+		}
+		nodes[node.ID] = node
+	}
+
+	start := sourceNodeID(g, rootID)
+	if _, ok := nodes[start]; !ok {
+		fmt.Fprintf(logOutput, "----------------------------Root %s not found in the Debate Map nodes\n", rootID)
+		return cluster
+	}
+
+	seen := map[string]bool{start: true}
+	level := []string{start}
+	for d := 0; len(level) > 0; d++ {
+		next := []string{}
+		for _, id := range level {
+			node := nodes[id]
+			title := node.Current.Title.Base
+			if t, ok := titles[node.CurrentRevision]; ok {
+				title = t
+			}
+			dn := dotNode{ID: "s:" + id, Label: dotLabel(title, id)}
+			switch node.Type {
+			case NODE_TYPE_CLAIM:
+				dn.Shape = "box"
+			case NODE_TYPE_ARGUMENT:
+				dn.Shape = "diamond"
+				dn.Color = dotPolarityColor(node.Polarity == ARGUMENT_POLARITY_PRO)
+				if node.MultiPremise {
+					dn.Label = dotLabel(title, id+"\nmulti-premise")
+				}
+			default:
+				dn.Shape = "folder"
+			}
+			cluster.Nodes = append(cluster.Nodes, dn)
+			if d == depth {
+				continue
+			}
+
+			for _, child := range sortedChildren(node) {
+				if _, ok := nodes[child.ID]; !ok {
+					fmt.Fprintf(logOutput, "----------------------------Child %s of %s not found\n", child.ID, id)
+					continue
+				}
+				edge := dotEdge{From: "s:" + id, To: "s:" + child.ID}
+				if child.Polarity != 0 {
+					edge.Color = dotPolarityColor(child.IsPro())
+				}
+				if node.MultiPremise {
+					edge.Label = fmt.Sprintf("%d", node.ChildOrder(child.ID))
+				}
+				cluster.Edges = append(cluster.Edges, edge)
+				if !seen[child.ID] {
+					seen[child.ID] = true
+					next = append(next, child.ID)
+				}
+			}
+		}
+		level = next
+	}
+	return cluster
+}
+
+// sourceNodeID finds the Debate Map node a claim or argument was converted from
+func sourceNodeID(g *Graph, rootID string) string {
+	id := rootID
+	for _, claim := range g.Claims {
+		if claim.Key == rootID {
+			id = claim.ID
+		}
+	}
+	for _, arg := range g.Arguments {
+		if arg.Key == rootID {
+			id = arg.ID
+		}
+	}
+	id = strings.TrimSuffix(id, MP_CLAIM_ID_SUFFIX)
+	return strings.TrimSuffix(id, CONVERTED_CLAIM_ID_SUFFIX)
+}
+
+// sortedChildren lists the children of a node in their childrenOrder, then by ID
+func sortedChildren(node DebateMapNode) []Child {
+	children := []Child{}
+	for key, value := range node.Children {
+		if child := NewChildFromData(key, value); child != nil {
+			children = append(children, *child)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		oi, oj := node.ChildOrder(children[i].ID), node.ChildOrder(children[j].ID)
+		if oi != oj {
+			return oi != 0 && (oj == 0 || oi < oj)
+		}
+		return children[i].ID < children[j].ID
+	})
+	return children
+}
+
+// renderConverted draws the Claims and Arguments below the root, following the inferences, base claims and premises.
+// The documents that have no Debate Map node of their own (MP claims, claims converted from categories,
+// and the intervening arguments) are dashed.
+func renderConverted(g *Graph, rootID string, depth int) dotCluster {
+	cluster := dotCluster{Name: "converted", Label: "Canonical Debate"}
+	claims := g.ClaimsByArangoID()
+	args := g.ArgumentsByArangoID()
+
+	out := map[string][]dotEdge{}
+	for _, inference := range g.Inferences {
+		edge := dotEdge{From: inference.From, To: inference.To}
+		if arg, ok := args[inference.To]; ok {
+			edge.Color = dotPolarityColor(arg.Pro)
+		}
+		out[inference.From] = append(out[inference.From], edge)
+	}
+	for _, bc := range g.BaseClaims {
+		out[bc.From] = append(out[bc.From], dotEdge{From: bc.From, To: bc.To})
+	}
+	for claimID, premises := range g.PremisesByClaim() {
+		for _, premise := range premises {
+			out[claimID] = append(out[claimID], dotEdge{From: premise.From, To: premise.To, Label: fmt.Sprintf("%d", premise.Order)})
+		}
+	}
+
+	start := ""
+	for handle, claim := range claims {
+		if claim.ID == rootID || claim.Key == rootID || handle == rootID {
+			start = handle
+		}
+	}
+	for handle, arg := range args {
+		if start == "" && (arg.ID == rootID || arg.Key == rootID || handle == rootID) {
+			start = handle
+		}
+	}
+	if start == "" {
+		// A Debate Map node that only exists in its converted form
+		for _, suffix := range []string{MP_CLAIM_ID_SUFFIX, CONVERTED_CLAIM_ID_SUFFIX} {
+			for handle, claim := range claims {
+				if claim.ID == rootID+suffix {
+					start = handle
+				}
+			}
+		}
+	}
+	if start == "" {
+		fmt.Fprintf(logOutput, "----------------------------Root %s not found in the converted graph\n", rootID)
+		return cluster
+	}
+
+	seen := map[string]bool{start: true}
+	level := []string{start}
+	for d := 0; len(level) > 0; d++ {
+		next := []string{}
+		for _, handle := range level {
+			if claim, ok := claims[handle]; ok {
+				label := claim.ID
+				if claim.MultiPremise {
+					label += "\nmulti-premise"
+				}
+				cluster.Nodes = append(cluster.Nodes, dotNode{
+					ID:          handle,
+					Label:       dotLabel(claim.Title, label),
+					Shape:       "box",
+					Synthesized: strings.HasSuffix(claim.ID, MP_CLAIM_ID_SUFFIX) || strings.HasSuffix(claim.ID, CONVERTED_CLAIM_ID_SUFFIX),
+				})
+			} else if arg, ok := args[handle]; ok {
+				cluster.Nodes = append(cluster.Nodes, dotNode{
+					ID:          handle,
+					Label:       dotLabel(arg.Title, arg.ID),
+					Shape:       "diamond",
+					Color:       dotPolarityColor(arg.Pro),
+					Synthesized: strings.Contains(arg.ID, INTERVENING_ARGUMENT_ID_SEPARATOR),
+				})
+			}
+			if d == depth {
+				continue
+			}
+
+			for _, edge := range out[handle] {
+				cluster.Edges = append(cluster.Edges, edge)
+				if !seen[edge.To] {
+					seen[edge.To] = true
+					next = append(next, edge.To)
+				}
+			}
+		}
+		level = next
+	}
+	return cluster
+}
+
+func dotPolarityColor(pro bool) string {
+	if pro {
+		return DOT_COLOR_PRO
+	}
+	return DOT_COLOR_CON
+}
+
+// dotLabel wraps the title, and puts the ID under it
+func dotLabel(title, id string) string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(title) {
+		if line != "" && len(line)+1+len(word) > DOT_LABEL_WIDTH {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(append(lines, id), "\n")
+}
+
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + strings.Replace(s, "\n", `\n`, -1) + `"`
+}
+
+// writeDOT writes the clusters side by side. Node IDs are only drawn once, even when the walk reaches them twice.
+func writeDOT(w io.Writer, clusters []dotCluster) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph debate {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	for _, cluster := range clusters {
+		fmt.Fprintf(b, "  subgraph %s {\n", dotQuote("cluster_"+cluster.Name))
+		fmt.Fprintf(b, "    label=%s;\n", dotQuote(cluster.Label))
+		drawn := map[string]bool{}
+		for _, node := range cluster.Nodes {
+			if drawn[node.ID] {
+				continue
+			}
+			drawn[node.ID] = true
+			attrs := []string{"label=" + dotQuote(node.Label), "shape=" + node.Shape}
+			if node.Color != "" {
+				attrs = append(attrs, "color="+dotQuote(node.Color), "fontcolor="+dotQuote(node.Color))
+			}
+			if node.Synthesized {
+				attrs = append(attrs, "style=dashed")
+				if node.Color == "" {
+					attrs = append(attrs, "color="+dotQuote(DOT_COLOR_SYNTHESIZED))
+				}
+			}
+			fmt.Fprintf(b, "    %s [%s];\n", dotQuote(node.ID), strings.Join(attrs, ", "))
+		}
+		for _, edge := range cluster.Edges {
+			attrs := []string{}
+			if edge.Label != "" {
+				attrs = append(attrs, "label="+dotQuote(edge.Label))
+			}
+			if edge.Color != "" {
+				attrs = append(attrs, "color="+dotQuote(edge.Color))
+			}
+			fmt.Fprintf(b, "    %s -> %s", dotQuote(edge.From), dotQuote(edge.To))
+			if len(attrs) > 0 {
+				fmt.Fprintf(b, " [%s]", strings.Join(attrs, ", "))
+			}
+			b.WriteString(";\n")
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func clusterIDs(cluster dotCluster) ([]string, []string) {
+	nodes := []string{}
+	for _, node := range cluster.Nodes {
+		nodes = append(nodes, node.ID)
+	}
+	edges := []string{}
+	for _, edge := range cluster.Edges {
+		edges = append(edges, edge.From+" -> "+edge.To)
+	}
+	sort.Strings(nodes)
+	sort.Strings(edges)
+	return nodes, edges
+}
+
+func TestRenderConverted(t *testing.T) {
+	g := testGraph(t)
+	handle := func(collection, id string) string {
+		return collection + "/" + documentKey(collection, id)
+	}
+	r, a, c := handle("claims", "R"), handle("arguments", "A"), handle("arguments", "C")
+	mp, x := handle("claims", "A"+MP_CLAIM_ID_SUFFIX), handle("claims", "X")
+	p1, p2 := handle("claims", "P1"), handle("claims", "P2")
+
+	// Each level adds the documents the previous one points to
+	tests := []struct {
+		Depth int
+		Nodes []string
+		Edges []string
+	}{
+		{0, []string{r}, []string{}},
+		{1, []string{r, a, c}, []string{r + " -> " + a, r + " -> " + c}},
+		{2, []string{r, a, c, mp, x}, []string{r + " -> " + a, r + " -> " + c, a + " -> " + mp, c + " -> " + x}},
+		{3, []string{r, a, c, mp, x, p1, p2}, []string{r + " -> " + a, r + " -> " + c, a + " -> " + mp, c + " -> " + x, mp + " -> " + p1, mp + " -> " + p2}},
+	}
+	for _, test := range tests {
+		sort.Strings(test.Nodes)
+		sort.Strings(test.Edges)
+		nodes, edges := clusterIDs(renderConverted(g, "R", test.Depth))
+		if strings.Join(nodes, ",") != strings.Join(test.Nodes, ",") || strings.Join(edges, ",") != strings.Join(test.Edges, ",") {
+			t.Errorf("at depth %d drew the nodes %v and edges %v, expected %v and %v", test.Depth, nodes, edges, test.Nodes, test.Edges)
+		}
+	}
+
+	// A subtree starts from any document, by ID or _key; the MP claim of A is found from its node ID too
+	subtree := []string{c, x}
+	sort.Strings(subtree)
+	if nodes, _ := clusterIDs(renderConverted(g, documentKey("arguments", "C"), 1)); strings.Join(nodes, ",") != strings.Join(subtree, ",") {
+		t.Errorf("the subtree of C has the nodes %v", nodes)
+	}
+	if nodes, _ := clusterIDs(renderConverted(g, "A", 0)); len(nodes) != 1 || nodes[0] != a {
+		t.Errorf("the subtree of A has the nodes %v", nodes)
+	}
+	if nodes, _ := clusterIDs(renderConverted(g, "unknown", 3)); len(nodes) != 0 {
+		t.Errorf("the subtree of an unknown root has the nodes %v", nodes)
+	}
+}
+
+func TestRenderSource(t *testing.T) {
+	g := testGraph(t)
+	root := DebateMapRoot{}
+	if err := json.Unmarshal([]byte(testExport), &root); err != nil {
+		t.Fatalf("reading the test export: %s", err.Error())
+	}
+	cluster := renderSource(root, g, "R", 1)
+	nodes, edges := clusterIDs(cluster)
+	if strings.Join(nodes, ",") != "s:A,s:C,s:R" || strings.Join(edges, ",") != "s:R -> s:A,s:R -> s:C" {
+		t.Errorf("at depth 1 drew the nodes %v and edges %v", nodes, edges)
+	}
+	for _, edge := range cluster.Edges {
+		if (edge.To == "s:A" && edge.Color != DOT_COLOR_PRO) || (edge.To == "s:C" && edge.Color != DOT_COLOR_CON) {
+			t.Errorf("the edge to %s has the color %s", edge.To, edge.Color)
+		}
+	}
+
+	// The premises of the multi-premise argument are numbered in their order
+	cluster = renderSource(root, g, "A", 1)
+	labels := map[string]string{}
+	for _, edge := range cluster.Edges {
+		labels[edge.To] = edge.Label
+	}
+	if labels["s:P1"] != "1" || labels["s:P2"] != "2" {
+		t.Errorf("the premises of A are labeled %v", labels)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := testGraph(t)
+	b := &bytes.Buffer{}
+	if err := writeDOT(b, []dotCluster{renderConverted(g, "R", 3)}); err != nil {
+		t.Fatalf("writeDOT: %s", err.Error())
+	}
+	dot := b.String()
+	if !strings.HasPrefix(dot, "digraph debate {\n") || !strings.HasSuffix(dot, "  }\n}\n") {
+		t.Errorf("the DOT output is not a digraph with a cluster:\n%s", dot)
+	}
+	mp := "claims/" + documentKey("claims", "A"+MP_CLAIM_ID_SUFFIX)
+	expected := []string{
+		`subgraph "cluster_converted" {`,
+		`label="Canonical Debate";`,
+		// The MP claim has no Debate Map node of its own
+		`"` + mp + `" [label="Nuclear power is cleaner and\ncheaper than coal power in the\nlong run.\nA-mp\nmulti-premise", shape=box, style=dashed, color="gray45"];`,
+		`"` + mp + `" -> "claims/` + documentKey("claims", "P2") + `" [label="2"];`,
+		`"claims/` + documentKey("claims", "R") + `" -> "arguments/` + documentKey("arguments", "C") + `" [color="red3"];`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(dot, line) {
+			t.Errorf("the DOT output has no %s:\n%s", line, dot)
+		}
+	}
+	// 7 documents, each drawn once, and 6 edges
+	if nodes, edges := strings.Count(dot, "shape="), strings.Count(dot, " -> "); nodes != 7 || edges != 6 {
+		t.Errorf("drew %d nodes and %d edges, expected 7 and 6", nodes, edges)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The ArangoMiGO migrations are built into the importer, so it can set up an empty server by itself
+//
+//go:embed migrations/*.migration
+var migrationFiles embed.FS
+
+const MIGRATION_TYPE_DATABASE = "database"
+const MIGRATION_TYPE_COLLECTION = "collection"
+const MIGRATION_TYPE_GRAPH = "graph"
+const MIGRATION_ACTION_CREATE = "create"
+
+// A Migration is one ArangoMiGO migration file. Only the "create" action is supported.
+type Migration struct {
+	File            string                    `yaml:"-"`
+	Type            string                    `yaml:"type"`
+	Action          string                    `yaml:"action"`
+	Name            string                    `yaml:"name"`
+	Allowed         []MigrationUser           `yaml:"allowed"`
+	EdgeDefinitions []MigrationEdgeDefinition `yaml:"edgedefinitions"`
+}
+
+type MigrationUser struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type MigrationEdgeDefinition struct {
+	Collection string   `yaml:"collection"`
+	From       []string `yaml:"from"`
+	To         []string `yaml:"to"`
+}
+
+// loadMigrations reads the embedded migrations in the order of the versions their file names start with
+// (1.0_..., 1.1_..., 1.10_...), replacing the ${name} variables with the extras, as ArangoMiGO does
+func loadMigrations(extras map[string]string) ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sortMigrationNames(names)
+
+	migrations := []Migration{}
+	for _, name := range names {
+		data, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		expanded := os.Expand(string(data), func(key string) string { return extras[key] })
+		migration := Migration{File: name}
+		if err := yaml.Unmarshal([]byte(expanded), &migration); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		if migration.Action != MIGRATION_ACTION_CREATE {
+			return nil, fmt.Errorf("%s: unsupported action %q", name, migration.Action)
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// sortMigrationNames sorts the file names by the numbers of their version, so 1.10 comes after 1.9
+func sortMigrationNames(names []string) {
+	version := func(name string) []int {
+		numbers := []int{}
+		for _, part := range strings.Split(strings.SplitN(name, "_", 2)[0], ".") {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				n = -1
+			}
+			numbers = append(numbers, n)
+		}
+		return numbers
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		vi, vj := version(names[i]), version(names[j])
+		for k := 0; k < len(vi) && k < len(vj); k++ {
+			if vi[k] != vj[k] {
+				return vi[k] < vj[k]
+			}
+		}
+		if len(vi) != len(vj) {
+			return len(vi) < len(vj)
+		}
+		return names[i] < names[j]
+	})
+}
+
+// applySchema applies the migrations that are missing from the server, then the schema rules, indexes
+// and search view of the collections, and reports what it did.
+// It only creates what doesn't exist yet, so it can be run any number of times.
+func applySchema(client driver.Client, dbName string, extras map[string]string) (driver.Database, []string, error) {
+	migrations, err := loadMigrations(extras)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, report, err := applyMigrations(client, dbName, migrations)
+	if err != nil {
+		return nil, report, err
+	}
+
+	rules, err := applyCollectionSchemas(client.Connection(), db)
+	report = append(report, rules...)
+	if err != nil {
+		return nil, report, err
+	}
+	indexes, err := ensureIndexes(db)
+	report = append(report, indexes...)
+	if err != nil {
+		return nil, report, err
+	}
+	views, err := ensureSearchView(db)
+	report = append(report, views...)
+	if err != nil {
+		return nil, report, err
+	}
+	return db, report, nil
+}
+
+// applyMigrations creates the database, collections and graphs of the migrations that are missing from the server.
+// The database migration creates the database named by dbName, whatever name the migration file gives it.
+func applyMigrations(client driver.Client, dbName string, migrations []Migration) (driver.Database, []string, error) {
+	report := []string{}
+	var db driver.Database
+	var err error
+	openDB := func() error {
+		if db != nil {
+			return nil
+		}
+		db, err = client.Database(nil, dbName)
+		return err
+	}
+
+	for _, migration := range migrations {
+		switch migration.Type {
+		case MIGRATION_TYPE_DATABASE:
+			exists, err := client.DatabaseExists(nil, dbName)
+			if err != nil {
+				return nil, report, err
+			}
+			if exists {
+				report = append(report, fmt.Sprintf("%s: database %s already exists", migration.File, dbName))
+				continue
+			}
+			users := []driver.CreateDatabaseUserOptions{}
+			for _, user := range migration.Allowed {
+				if user.Username != "" {
+					users = append(users, driver.CreateDatabaseUserOptions{UserName: user.Username, Password: user.Password})
+				}
+			}
+			if db, err = client.CreateDatabase(nil, dbName, &driver.CreateDatabaseOptions{Users: users}); err != nil {
+				return nil, report, fmt.Errorf("%s: %s", migration.File, err.Error())
+			}
+			report = append(report, fmt.Sprintf("%s: created database %s", migration.File, dbName))
+
+		case MIGRATION_TYPE_COLLECTION:
+			if err := openDB(); err != nil {
+				return nil, report, err
+			}
+			created, err := ensureCollection(db, migration.Name, driver.CollectionTypeDocument)
+			if err != nil {
+				return nil, report, fmt.Errorf("%s: %s", migration.File, err.Error())
+			}
+			if created {
+				report = append(report, fmt.Sprintf("%s: created collection %s", migration.File, migration.Name))
+			} else {
+				report = append(report, fmt.Sprintf("%s: collection %s already exists", migration.File, migration.Name))
+			}
+
+		case MIGRATION_TYPE_GRAPH:
+			if err := openDB(); err != nil {
+				return nil, report, err
+			}
+			exists, err := db.GraphExists(nil, migration.Name)
+			if err != nil {
+				return nil, report, err
+			}
+			if !exists {
+				definitions := []driver.EdgeDefinition{}
+				for _, definition := range migration.EdgeDefinitions {
+					definitions = append(definitions, driver.EdgeDefinition{Collection: definition.Collection, From: definition.From, To: definition.To})
+				}
+				if _, err := db.CreateGraph(nil, migration.Name, &driver.CreateGraphOptions{EdgeDefinitions: definitions}); err != nil {
+					return nil, report, fmt.Errorf("%s: %s", migration.File, err.Error())
+				}
+				report = append(report, fmt.Sprintf("%s: created graph %s", migration.File, migration.Name))
+				continue
+			}
+			report = append(report, fmt.Sprintf("%s: graph %s already exists", migration.File, migration.Name))
+			// The edge collections of an existing graph could still have been dropped
+			for _, definition := range migration.EdgeDefinitions {
+				created, err := ensureCollection(db, definition.Collection, driver.CollectionTypeEdge)
+				if err != nil {
+					return nil, report, fmt.Errorf("%s: %s", migration.File, err.Error())
+				}
+				if created {
+					report = append(report, fmt.Sprintf("%s: created edge collection %s", migration.File, definition.Collection))
+				}
+			}
+
+		default:
+			return nil, report, fmt.Errorf("%s: unsupported migration type %q", migration.File, migration.Type)
+		}
+	}
+	if err := openDB(); err != nil {
+		return nil, report, err
+	}
+	return db, report, nil
+}
+
+// ensureCollection creates the collection if it doesn't exist, and tells whether it did
+func ensureCollection(db driver.Database, name string, colType driver.CollectionType) (bool, error) {
+	exists, err := db.CollectionExists(nil, name)
+	if err != nil || exists {
+		return false, err
+	}
+	_, err = db.CreateCollection(nil, name, &driver.CreateCollectionOptions{Type: colType})
+	return err == nil, err
+}
+
+// SchemaFlags are the ArangoMiGO "extras": the user given access to the database when it is created
+type SchemaFlags struct {
+	Username string
+	Password string
+}
+
+func (sf *SchemaFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&sf.Username, "db-user", "", "user to give access to the database, when it is created")
+	fs.StringVar(&sf.Password, "db-password", "", "password of the -db-user")
+}
+
+func (sf SchemaFlags) Extras() map[string]string {
+	return map[string]string{"username": sf.Username, "password": sf.Password}
+}
+
+// ensureSchema applies the missing migrations and prints the report, and returns the database
+func ensureSchema(cf ConnectionFlags, sf SchemaFlags) driver.Database {
+	client := OpenArangoClient(cf.Server, cf.Username, cf.Password)
+	db, report, err := applySchema(client, cf.DBName, sf.Extras())
+	for _, line := range report {
+		fmt.Fprintln(logOutput, line)
+	}
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error applying the schema:", err.Error())
+		panic(err.Error())
+	}
+	return db
+}
+
+// runSchema manages the database schema:
+//
+//	go run *.go schema apply [-h ...] [-db ...] [--db-user myuser --db-password mypassword]
+//	go run *.go schema rules
+func runSchema(args []string) {
+	if len(args) == 0 || (args[0] != "apply" && args[0] != "rules") {
+		fmt.Fprintln(logOutput, "Usage: schema apply [flags] | schema rules")
+		os.Exit(2)
+	}
+	if args[0] == "rules" {
+		rules := map[string]*JSONSchema{}
+		for _, col := range schemaCollections {
+			rules[col.Name] = documentSchema(col.Type)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rules); err != nil {
+			fmt.Fprintln(logOutput, "Error writing the schema rules:", err.Error())
+			panic(err.Error())
+		}
+		return
+	}
+
+	fs := flag.NewFlagSet("schema apply", flag.ExitOnError)
+	cf := ConnectionFlags{}
+	cf.Register(fs)
+	sf := SchemaFlags{}
+	sf.Register(fs)
+	fs.Parse(args[1:])
+
+	ensureSchema(cf, sf)
+	fmt.Fprintln(logOutput, "Done.")
+}
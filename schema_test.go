@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// migrationServer is a server with databases of collections and graphs, as far as the migrations look at them
+type migrationServer struct {
+	driver.Client
+	databases map[string]*migrationDB
+}
+
+func (s migrationServer) DatabaseExists(ctx context.Context, name string) (bool, error) {
+	_, ok := s.databases[name]
+	return ok, nil
+}
+
+func (s migrationServer) Database(ctx context.Context, name string) (driver.Database, error) {
+	return s.databases[name], nil
+}
+
+func (s migrationServer) CreateDatabase(ctx context.Context, name string, options *driver.CreateDatabaseOptions) (driver.Database, error) {
+	s.databases[name] = &migrationDB{collections: map[string]driver.CollectionType{}, graphs: map[string]bool{}}
+	return s.databases[name], nil
+}
+
+type migrationDB struct {
+	driver.Database
+	collections map[string]driver.CollectionType
+	graphs      map[string]bool
+}
+
+func (db *migrationDB) CollectionExists(ctx context.Context, name string) (bool, error) {
+	_, ok := db.collections[name]
+	return ok, nil
+}
+
+func (db *migrationDB) CreateCollection(ctx context.Context, name string, options *driver.CreateCollectionOptions) (driver.Collection, error) {
+	db.collections[name] = options.Type
+	return nil, nil
+}
+
+func (db *migrationDB) GraphExists(ctx context.Context, name string) (bool, error) {
+	return db.graphs[name], nil
+}
+
+// CreateGraph also creates the edge collections, as ArangoDB does
+func (db *migrationDB) CreateGraph(ctx context.Context, name string, options *driver.CreateGraphOptions) (driver.Graph, error) {
+	db.graphs[name] = true
+	for _, definition := range options.EdgeDefinitions {
+		db.collections[definition.Collection] = driver.CollectionTypeEdge
+	}
+	return nil, nil
+}
+
+func TestSortMigrationNames(t *testing.T) {
+	names := []string{"1.10_CreateViews.migration", "2.0_Rename.migration", "1.2_CreateArguments.migration", "1.9_CreateUsers.migration", "1.2.1_Fix.migration"}
+	sortMigrationNames(names)
+	expected := []string{"1.2_CreateArguments.migration", "1.2.1_Fix.migration", "1.9_CreateUsers.migration", "1.10_CreateViews.migration", "2.0_Rename.migration"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("sorted the migrations as %v, expected %v", names, expected)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(map[string]string{"username": "cdl", "password": "secret"})
+	if err != nil {
+		t.Fatalf("loadMigrations: %s", err.Error())
+	}
+	files := []string{}
+	for _, migration := range migrations {
+		files = append(files, migration.File)
+	}
+	// The database comes first, and every collection is created before the graphs linking it
+	if migrations[0].Type != MIGRATION_TYPE_DATABASE || !reflect.DeepEqual(migrations[0].Allowed, []MigrationUser{{"cdl", "secret"}}) {
+		t.Errorf("the first migration is %+v, expected the database with the user cdl", migrations[0])
+	}
+	created := map[string]bool{}
+	for _, migration := range migrations[1:] {
+		switch migration.Type {
+		case MIGRATION_TYPE_COLLECTION:
+			created[migration.Name] = true
+		case MIGRATION_TYPE_GRAPH:
+			for _, definition := range migration.EdgeDefinitions {
+				for _, vertex := range append(append([]string{}, definition.From...), definition.To...) {
+					if !created[vertex] {
+						t.Errorf("%s links %s before it is created", migration.File, vertex)
+					}
+				}
+				created[definition.Collection] = true
+			}
+		}
+	}
+	// The migrations create every collection of the graph
+	for _, collection := range schemaCollections {
+		if !created[collection.Name] {
+			t.Errorf("no migration creates the collection %s (migrations: %s)", collection.Name, strings.Join(files, ", "))
+		}
+	}
+}
+
+func TestApplyMigrations(t *testing.T) {
+	migrations, err := loadMigrations(nil)
+	if err != nil {
+		t.Fatalf("loadMigrations: %s", err.Error())
+	}
+
+	// A database created before the ratings and users
+	old := &migrationDB{collections: map[string]driver.CollectionType{}, graphs: map[string]bool{"debate_map": true}}
+	for _, name := range []string{"claims", "arguments"} {
+		old.collections[name] = driver.CollectionTypeDocument
+	}
+	for _, name := range []string{"inferences", "base_claims", "premises"} {
+		old.collections[name] = driver.CollectionTypeEdge
+	}
+	server := migrationServer{databases: map[string]*migrationDB{"debates": old}}
+	_, report, err := applyMigrations(server, "debates", migrations)
+	if err != nil {
+		t.Fatalf("applyMigrations: %s", err.Error())
+	}
+	expected := []string{
+		"1.0_CreateCDDB.migration: database debates already exists",
+		"1.1_CreateClaimsCollection.migration: collection claims already exists",
+		"1.2_CreateArgumentsCollection.migration: collection arguments already exists",
+		"1.3_CreateEdges.migration: graph debate_map already exists",
+		"1.4_CreateRatingsCollection.migration: created collection ratings",
+		"1.5_CreateRatingsGraph.migration: created graph debate_ratings",
+		"1.6_CreateUsersCollection.migration: created collection users",
+		"1.7_CreateUsersGraph.migration: created graph debate_users",
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("applied the migrations as:\n%s\nexpected:\n%s", strings.Join(report, "\n"), strings.Join(expected, "\n"))
+	}
+	if err := checkCollections(old); err != nil {
+		t.Errorf("checkCollections after the migrations: %s", err.Error())
+	}
+
+	// An edge collection dropped from an existing graph is created again, and nothing else
+	delete(old.collections, "premises")
+	_, report, err = applyMigrations(server, "debates", migrations)
+	if err != nil {
+		t.Fatalf("applyMigrations again: %s", err.Error())
+	}
+	createdLines := []string{}
+	for _, line := range report {
+		if strings.Contains(line, "created") {
+			createdLines = append(createdLines, line)
+		}
+	}
+	if !reflect.DeepEqual(createdLines, []string{"1.3_CreateEdges.migration: created edge collection premises"}) {
+		t.Errorf("the second run created %v, expected only the premises", createdLines)
+	}
+
+	// An empty server gets the database under the given name
+	server = migrationServer{databases: map[string]*migrationDB{}}
+	if _, report, err = applyMigrations(server, "fresh", migrations); err != nil {
+		t.Fatalf("applyMigrations on an empty server: %s", err.Error())
+	}
+	if report[0] != "1.0_CreateCDDB.migration: created database fresh" || server.databases["fresh"] == nil {
+		t.Errorf("the empty server got the report %v", report)
+	} else if err := checkCollections(server.databases["fresh"]); err != nil {
+		t.Errorf("checkCollections on the new database: %s", err.Error())
+	}
+}
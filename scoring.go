@@ -0,0 +1,243 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/canonical-debate-lab/arango-importer/evaluation"
+)
+
+const SCORER_NONE = "none"
+const SCORER_CANONICAL = "canonical"
+
+// How much the score a document starts with (its prior) counts against the arguments about it,
+// as the weight of one fully relevant and fully strong argument
+const SCORE_PRIOR_WEIGHT = 1.0
+
+// Scorers compute the truth of the claims and the relevance and strength of the arguments of the graph.
+// The import starts every score from a constant, which a scorer replaces.
+var scorers = map[string]func(g *Graph) error{
+	SCORER_CANONICAL: scoreCanonical,
+}
+
+func scorerNames() []string {
+	names := []string{SCORER_NONE}
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// parseScorer returns the named scorer, or nil for "none"
+func parseScorer(name string) (func(g *Graph) error, error) {
+	if name == SCORER_NONE {
+		return nil, nil
+	}
+	if scorer, ok := scorers[name]; ok {
+		return scorer, nil
+	}
+	return nil, fmt.Errorf("unknown scorer %q (expected one of: %s)", name, strings.Join(scorerNames(), ", "))
+}
+
+// scoreCanonical propagates the scores bottom-up, following the Canonical Debate rules:
+//   - the strength of an argument is the truth of the claim it is based on;
+//   - the truth of a claim is its prior, moved towards 1 by its pro arguments and towards 0 by its con arguments,
+//     each weighing its relevance times its strength;
+//   - the relevance of an argument is moved in the same way by the arguments about it;
+//   - the truth of a multi-premise claim starts from its premises, combined according to its PremiseRule
+//     (see evaluation.Combine).
+//
+// The priors are the average of the ratings of the documents, or the default scores, so scoring a graph
+// that was already scored gives the same result. Documents in a cycle keep their prior for the argument
+// that closes it.
+func scoreCanonical(g *Graph) error {
+	resetScores(g)
+	claims := map[string]int{}
+	for i, claim := range g.Claims {
+		claims[claim.ArangoID()] = i
+	}
+	args := map[string]int{}
+	for i, arg := range g.Arguments {
+		args[arg.ArangoID()] = i
+	}
+	argsByTarget := map[string][]string{}
+	for _, inference := range g.Inferences {
+		argsByTarget[inference.From] = append(argsByTarget[inference.From], inference.To)
+	}
+	baseClaims := map[string]string{}
+	for _, bc := range g.BaseClaims {
+		baseClaims[bc.From] = bc.To
+	}
+	premises := g.PremisesByClaim()
+
+	const visiting, done = 1, 2
+	state := map[string]int{}
+	var scoreErr error
+	var scoreClaim func(handle string) float64
+	var scoreArgument func(handle string) (float64, float64)
+
+	weigh := func(prior float64, handles []string) float64 {
+		pro, con := 0.0, 0.0
+		for _, handle := range handles {
+			i, ok := args[handle]
+			if !ok {
+				continue
+			}
+			relevance, strength := scoreArgument(handle)
+			if g.Arguments[i].Pro {
+				pro += relevance * strength
+			} else {
+				con += relevance * strength
+			}
+		}
+		return (pro + prior*SCORE_PRIOR_WEIGHT) / (pro + con + SCORE_PRIOR_WEIGHT)
+	}
+
+	scoreClaim = func(handle string) float64 {
+		i := claims[handle]
+		if state[handle] != 0 {
+			return float64(g.Claims[i].Truth)
+		}
+		state[handle] = visiting
+		truth := float64(g.Claims[i].Truth)
+		if claimPremises := premises[handle]; len(claimPremises) > 0 {
+			truths := []evaluation.Premise{}
+			for _, premise := range claimPremises {
+				if _, ok := claims[premise.To]; ok {
+					truths = append(truths, evaluation.Premise{Order: premise.Order, Truth: scoreClaim(premise.To)})
+				}
+			}
+			combined, err := evaluation.Combine(g.Claims[i].PremiseRule, truths)
+			if err != nil && scoreErr == nil {
+				scoreErr = fmt.Errorf("claim %s: %s", g.Claims[i].ID, err.Error())
+			} else if err == nil {
+				truth = combined
+			}
+		}
+		g.Claims[i].Truth = float32(weigh(truth, argsByTarget[handle]))
+		state[handle] = done
+		return float64(g.Claims[i].Truth)
+	}
+
+	scoreArgument = func(handle string) (float64, float64) {
+		i := args[handle]
+		if state[handle] != 0 {
+			return float64(g.Arguments[i].Relevance), float64(g.Arguments[i].Str)
+		}
+		state[handle] = visiting
+		if base, ok := baseClaims[handle]; ok {
+			if _, ok := claims[base]; ok {
+				g.Arguments[i].Str = float32(scoreClaim(base))
+			}
+		}
+		g.Arguments[i].Relevance = float32(weigh(float64(g.Arguments[i].Relevance), argsByTarget[handle]))
+		state[handle] = done
+		return float64(g.Arguments[i].Relevance), float64(g.Arguments[i].Str)
+	}
+
+	for _, claim := range g.Claims {
+		scoreClaim(claim.ArangoID())
+	}
+	for _, arg := range g.Arguments {
+		scoreArgument(arg.ArangoID())
+	}
+	return scoreErr
+}
+
+// resetScores sets the scores back to those the import starts with: the defaults, or the average of the ratings
+func resetScores(g *Graph) {
+	for i := range g.Claims {
+		g.Claims[i].Truth = CLAIM_DEFAULT_TRUTH
+	}
+	for i := range g.Arguments {
+		g.Arguments[i].Relevance = ARGUMENT_DEFAULT_RELEVANCE
+		g.Arguments[i].Str = ARGUMENT_DEFAULT_STRENGTH
+	}
+	averageRatings(g)
+}
+
+// scoreGraph runs the scorer on the graph and prints how the scores are spread
+func scoreGraph(g *Graph, name string, scorer func(g *Graph) error) {
+	if err := scorer(g); err != nil {
+		fmt.Fprintln(logOutput, "Error scoring the graph:", err.Error())
+		panic(err.Error())
+	}
+	truth := 0.0
+	for _, claim := range g.Claims {
+		truth += float64(claim.Truth)
+	}
+	if len(g.Claims) > 0 {
+		truth /= float64(len(g.Claims))
+	}
+	fmt.Fprintf(logOutput, "Scored %d claims and %d arguments (%s), average truth %.3f\n", len(g.Claims), len(g.Arguments), name, truth)
+}
+
+// runScore scores a graph already imported into the database, and updates the scores of its documents:
+//
+//	go run *.go score --scorer canonical
+func runScore(args []string) {
+	fs := flag.NewFlagSet("score", flag.ExitOnError)
+	cf := ConnectionFlags{}
+	cf.Register(fs)
+	var name string
+	var dryRun bool
+	fs.StringVar(&name, "scorer", SCORER_CANONICAL, "scoring algorithm ("+strings.Join(scorerNames()[1:], ", ")+")")
+	fs.BoolVar(&dryRun, "dry-run", false, "compute the scores without updating the database")
+	fs.Parse(args)
+
+	scorer, err := parseScorer(name)
+	if err == nil && scorer == nil {
+		err = fmt.Errorf("a scorer is required")
+	}
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error in --scorer:", err.Error())
+		panic(err.Error())
+	}
+
+	db := cf.Open()
+	g, err := loadGraphFromDB(db)
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error reading the graph:", err.Error())
+		panic(err.Error())
+	}
+	scoreGraph(g, name, scorer)
+	if dryRun {
+		fmt.Fprintln(logOutput, "Done (dry run, nothing updated).")
+		return
+	}
+
+	claimKeys := []string{}
+	claimUpdates := []map[string]interface{}{}
+	for _, claim := range g.Claims {
+		claimKeys = append(claimKeys, claim.Key)
+		claimUpdates = append(claimUpdates, map[string]interface{}{"truth": claim.Truth})
+	}
+	argKeys := []string{}
+	argUpdates := []map[string]interface{}{}
+	for _, arg := range g.Arguments {
+		argKeys = append(argKeys, arg.Key)
+		argUpdates = append(argUpdates, map[string]interface{}{"relevance": arg.Relevance, "strength": arg.Str})
+	}
+	updateScores(openCollection(db, "claims", false), claimKeys, claimUpdates)
+	updateScores(openCollection(db, "arguments", false), argKeys, argUpdates)
+	fmt.Fprintln(logOutput, "Done.")
+}
+
+func updateScores(col driver.Collection, keys []string, updates []map[string]interface{}) {
+	if len(keys) == 0 {
+		return
+	}
+	_, errs, err := col.UpdateDocuments(nil, keys, updates)
+	if err == nil {
+		err = errs.FirstNonNil()
+	}
+	if err != nil {
+		fmt.Fprintf(logOutput, "Error updating the scores of %s: %s\n", col.Name(), err.Error())
+		panic(err.Error())
+	}
+	fmt.Fprintf(logOutput, "Updated the scores of %d documents in %s\n", len(keys), col.Name())
+}
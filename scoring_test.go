@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestScoreCanonical(t *testing.T) {
+	g := testGraph(t)
+	if err := scoreCanonical(g); err != nil {
+		t.Fatalf("scoreCanonical: %s", err.Error())
+	}
+	claims := map[string]Claim{}
+	for _, claim := range g.Claims {
+		claims[claim.ID] = claim
+	}
+	args := map[string]Argument{}
+	for _, arg := range g.Arguments {
+		args[arg.ID] = arg
+	}
+
+	// The premises keep their prior of 0.5, and the multi-premise claim needs both of them (ALL).
+	// The argument A is based on it, with the relevance of its rating; the argument C on X.
+	// R starts from the average of its ratings, 0.6, and weighs A (pro) against C (con).
+	pro := 0.9 * 0.25
+	con := 1.0 * 0.5
+	tests := []struct {
+		Name     string
+		Score    float32
+		Expected float64
+	}{
+		{"truth of P1", claims["P1"].Truth, 0.5},
+		{"truth of A" + MP_CLAIM_ID_SUFFIX, claims["A"+MP_CLAIM_ID_SUFFIX].Truth, 0.25},
+		{"strength of A", args["A"].Str, 0.25},
+		{"relevance of A", args["A"].Relevance, 0.9},
+		{"strength of C", args["C"].Str, 0.5},
+		{"relevance of C", args["C"].Relevance, 1},
+		{"truth of R", claims["R"].Truth, (pro + 0.6*SCORE_PRIOR_WEIGHT) / (pro + con + SCORE_PRIOR_WEIGHT)},
+	}
+	for _, test := range tests {
+		if math.Abs(float64(test.Score)-test.Expected) > 1e-6 {
+			t.Errorf("the %s is %v, expected %v", test.Name, test.Score, test.Expected)
+		}
+	}
+}
+
+func TestScoreCanonicalSupport(t *testing.T) {
+	// A true claim supporting R, and a claim as likely as not attacking it, move R towards true
+	root := dedupRoot()
+	root.Nodes[2].Current.Title.Base = "Nuclear waste is dangerous."
+	root.NodeRatings = []NodeRatings{{ID: "D1", Ratings: []NodeRating{{Type: "truth", User: "u1", Value: 100}}}}
+	g := buildGraph(root)
+	if err := scoreCanonical(g); err != nil {
+		t.Fatalf("scoreCanonical: %s", err.Error())
+	}
+	if truth := g.Claims[0].Truth; g.Claims[0].ID != "R" || math.Abs(float64(truth)-(1+0.5)/(1+0.5+1)) > 1e-6 {
+		t.Errorf("the truth of %s is %v, expected R with 0.6", g.Claims[0].ID, truth)
+	}
+}
+
+func TestParseScorer(t *testing.T) {
+	if scorer, err := parseScorer(SCORER_NONE); scorer != nil || err != nil {
+		t.Errorf("parseScorer(none) returned a scorer, or the error %v", err)
+	}
+	if scorer, err := parseScorer(SCORER_CANONICAL); scorer == nil || err != nil {
+		t.Errorf("parseScorer(canonical) returned no scorer, or the error %v", err)
+	}
+	if _, err := parseScorer("magic"); err == nil || !strings.Contains(err.Error(), "none, canonical") {
+		t.Errorf("parseScorer(magic) returned the error %v", err)
+	}
+}
+
+func TestScoreCanonicalTwice(t *testing.T) {
+	// Scoring the scores read back from the database gives them again
+	g := testGraph(t)
+	if err := scoreCanonical(g); err != nil {
+		t.Fatalf("scoreCanonical: %s", err.Error())
+	}
+	claims := append([]Claim{}, g.Claims...)
+	args := append([]Argument{}, g.Arguments...)
+	if err := scoreCanonical(g); err != nil {
+		t.Fatalf("scoreCanonical: %s", err.Error())
+	}
+	for i, claim := range g.Claims {
+		if claim.Truth != claims[i].Truth {
+			t.Errorf("the truth of %s went from %v to %v", claim.ID, claims[i].Truth, claim.Truth)
+		}
+	}
+	for i, arg := range g.Arguments {
+		if arg.Relevance != args[i].Relevance || arg.Str != args[i].Str {
+			t.Errorf("the relevance and strength of %s went from %v and %v to %v and %v", arg.ID, args[i].Relevance, args[i].Str, arg.Relevance, arg.Str)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// The ArangoSearch view giving server-api full-text search on the claims and arguments.
+// Each text field is copied at write time into a field of the document's language (title_pt, note_en, ...),
+// indexed only with the analyzer of that language; a search looks in the fields of the lang it wants:
+//
+//	FOR d IN claim_search
+//	  SEARCH ANALYZER(d.title_pt IN TOKENS(@text, "text_pt"), "text_pt")
+//	  SORT BM25(d) DESC
+//	  RETURN d
+const SEARCH_VIEW_NAME = "claim_search"
+
+// Language of the text of a document whose language is unknown, and analyzer of the fields matched as they are (terms and lang)
+const SEARCH_DEFAULT_LANGUAGE = "en"
+const SEARCH_IDENTITY_ANALYZER = "identity"
+
+var searchCollections = []string{"claims", "arguments"}
+var searchTextFields = []string{"title", "negation", "question", "note"}
+
+// searchField is the field holding the text of a field in a language
+func searchField(field, code string) string {
+	return field + "_" + code
+}
+
+// searchLinks are the collections and fields of the view, with their analyzers
+func searchLinks() driver.ArangoSearchLinks {
+	links := driver.ArangoSearchLinks{}
+	for _, collection := range searchCollections {
+		fields := driver.ArangoSearchFields{}
+		for _, field := range searchTextFields {
+			for _, language := range languages {
+				fields[searchField(field, language.Code)] = driver.ArangoSearchElementProperties{Analyzers: []string{language.Analyzer}}
+			}
+		}
+		fields["terms"] = driver.ArangoSearchElementProperties{Analyzers: []string{SEARCH_IDENTITY_ANALYZER}}
+		fields["lang"] = driver.ArangoSearchElementProperties{Analyzers: []string{SEARCH_IDENTITY_ANALYZER}}
+		links[collection] = driver.ArangoSearchElementProperties{Fields: fields}
+	}
+	return links
+}
+
+// searchDocument is the document as it is written to a searched collection: its fields,
+// with a copy of each text field in the field of its language
+func searchDocument(item interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	code := SEARCH_DEFAULT_LANGUAGE
+	if lang, ok := doc["lang"].(string); ok {
+		for _, language := range languages {
+			if language.Code == lang {
+				code = lang
+			}
+		}
+	}
+	for _, field := range searchTextFields {
+		if text, ok := doc[field].(string); ok && text != "" {
+			doc[searchField(field, code)] = text
+		}
+	}
+	return doc, nil
+}
+
+// searchLinksMatch tells whether the view already indexes the wanted fields with the wanted analyzers.
+// The server fills in the other properties, so only those are compared.
+func searchLinksMatch(current, wanted driver.ArangoSearchLinks) bool {
+	if len(current) != len(wanted) {
+		return false
+	}
+	for collection, link := range wanted {
+		currentLink, ok := current[collection]
+		if !ok || len(currentLink.Fields) != len(link.Fields) {
+			return false
+		}
+		for field, properties := range link.Fields {
+			currentAnalyzers := append([]string{}, currentLink.Fields[field].Analyzers...)
+			wantedAnalyzers := append([]string{}, properties.Analyzers...)
+			sort.Strings(currentAnalyzers)
+			sort.Strings(wantedAnalyzers)
+			if !reflect.DeepEqual(currentAnalyzers, wantedAnalyzers) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ensureSearchView creates the view, or updates its links if they changed, and reports what it did
+func ensureSearchView(db driver.Database) ([]string, error) {
+	wanted := driver.ArangoSearchViewProperties{Links: searchLinks()}
+	exists, err := db.ViewExists(nil, SEARCH_VIEW_NAME)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if _, err := db.CreateArangoSearchView(nil, SEARCH_VIEW_NAME, &wanted); err != nil {
+			return nil, fmt.Errorf("creating the view %s: %s", SEARCH_VIEW_NAME, err.Error())
+		}
+		return []string{fmt.Sprintf("created view %s", SEARCH_VIEW_NAME)}, nil
+	}
+
+	view, err := db.View(nil, SEARCH_VIEW_NAME)
+	if err != nil {
+		return nil, err
+	}
+	searchView, err := view.ArangoSearchView()
+	if err != nil {
+		return nil, fmt.Errorf("view %s: %s", SEARCH_VIEW_NAME, err.Error())
+	}
+	current, err := searchView.Properties(nil)
+	if err != nil {
+		return nil, err
+	}
+	if searchLinksMatch(current.Links, wanted.Links) {
+		return []string{fmt.Sprintf("view %s is up to date", SEARCH_VIEW_NAME)}, nil
+	}
+	if err := searchView.SetProperties(nil, wanted); err != nil {
+		return nil, fmt.Errorf("updating the view %s: %s", SEARCH_VIEW_NAME, err.Error())
+	}
+	return []string{fmt.Sprintf("updated the links of view %s", SEARCH_VIEW_NAME)}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+func TestSearchLinks(t *testing.T) {
+	links := searchLinks()
+	for _, collection := range searchCollections {
+		fields := links[collection].Fields
+		// Every text field in every language, and terms and lang
+		if len(fields) != len(searchTextFields)*len(languages)+2 {
+			t.Errorf("%s indexes %d fields, expected %d", collection, len(fields), len(searchTextFields)*len(languages)+2)
+		}
+		for _, language := range languages {
+			for _, field := range searchTextFields {
+				if analyzers := fields[searchField(field, language.Code)].Analyzers; !reflect.DeepEqual(analyzers, []string{language.Analyzer}) {
+					t.Errorf("%s.%s is indexed with %v, expected only %s", collection, searchField(field, language.Code), analyzers, language.Analyzer)
+				}
+			}
+		}
+		if _, ok := fields["title"]; ok {
+			t.Errorf("%s indexes the title in every language", collection)
+		}
+	}
+}
+
+func TestSearchDocument(t *testing.T) {
+	claim := NewClaim(DebateMapNode{ID: "c1"})
+	claim.Title = "O Brasil deve reformar a previdência"
+	claim.Question = "O Brasil deve reformar a previdência?"
+	claim.Language = "pt"
+	doc, err := searchDocument(claim)
+	if err != nil {
+		t.Fatalf("searchDocument: %s", err.Error())
+	}
+	if doc["title_pt"] != claim.Title || doc["question_pt"] != claim.Question || doc["title"] != claim.Title || doc["lang"] != "pt" {
+		t.Errorf("unexpected document: %v", doc)
+	}
+	// Only the fields of its language, and only those with text
+	for field := range doc {
+		for _, language := range languages {
+			for _, text := range searchTextFields {
+				if field == searchField(text, language.Code) && field != "title_pt" && field != "question_pt" {
+					t.Errorf("the document has the field %s", field)
+				}
+			}
+		}
+	}
+
+	// A document of unknown language is searched as English
+	arg := NewArgument(DebateMapNode{ID: "a1"})
+	arg.Title = "Short"
+	doc, err = searchDocument(arg)
+	if err != nil {
+		t.Fatalf("searchDocument: %s", err.Error())
+	}
+	if doc["title_"+SEARCH_DEFAULT_LANGUAGE] != "Short" {
+		t.Errorf("the document of unknown language has no %s field: %v", searchField("title", SEARCH_DEFAULT_LANGUAGE), doc)
+	}
+}
+
+func TestSearchLinksMatch(t *testing.T) {
+	wanted := searchLinks()
+	// The server returns the links with the properties it filled in, and the analyzers in its own order
+	current := func() driver.ArangoSearchLinks {
+		links := driver.ArangoSearchLinks{}
+		for collection, link := range searchLinks() {
+			link.IncludeAllFields = new(bool)
+			link.StoreValues = driver.ArangoSearchStoreValuesNone
+			links[collection] = link
+		}
+		return links
+	}
+	if !searchLinksMatch(current(), wanted) {
+		t.Errorf("the links don't match themselves with the server's defaults")
+	}
+	reordered := current()
+	reordered["claims"].Fields["terms"] = driver.ArangoSearchElementProperties{Analyzers: []string{"text_en", SEARCH_IDENTITY_ANALYZER}}
+	wantedTwo := searchLinks()
+	wantedTwo["claims"].Fields["terms"] = driver.ArangoSearchElementProperties{Analyzers: []string{SEARCH_IDENTITY_ANALYZER, "text_en"}}
+	if !searchLinksMatch(reordered, wantedTwo) {
+		t.Errorf("the links don't match with the analyzers in another order")
+	}
+
+	changes := map[string]func(links driver.ArangoSearchLinks){
+		"a missing collection": func(links driver.ArangoSearchLinks) { delete(links, "arguments") },
+		"another collection": func(links driver.ArangoSearchLinks) {
+			links["ratings"] = driver.ArangoSearchElementProperties{}
+		},
+		"a missing field": func(links driver.ArangoSearchLinks) { delete(links["claims"].Fields, "title_pt") },
+		"an old field": func(links driver.ArangoSearchLinks) {
+			links["claims"].Fields["title"] = driver.ArangoSearchElementProperties{Analyzers: []string{"text_en"}}
+		},
+		"another analyzer": func(links driver.ArangoSearchLinks) {
+			links["claims"].Fields["title_pt"] = driver.ArangoSearchElementProperties{Analyzers: []string{"text_en"}}
+		},
+		"an analyzer more": func(links driver.ArangoSearchLinks) {
+			links["arguments"].Fields["note_fr"] = driver.ArangoSearchElementProperties{Analyzers: []string{"text_fr", "text_en"}}
+		},
+	}
+	for name, change := range changes {
+		links := current()
+		change(links)
+		if searchLinksMatch(links, wanted) {
+			t.Errorf("the links match with %s", name)
+		}
+	}
+}
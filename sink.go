@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// Sinks the import can write the converted graph to
+const SINK_ARANGO = "arango"
+const SINK_CYPHER = "cypher"
+const SINK_NEO4J_CSV = "neo4j-csv"
+const SINK_SQLITE = SQL_DIALECT_SQLITE
+const SINK_POSTGRES = SQL_DIALECT_POSTGRES
+
+var sinkNames = []string{SINK_ARANGO, SINK_CYPHER, SINK_NEO4J_CSV, SINK_SQLITE, SINK_POSTGRES}
+
+// Default output of the neo4j-csv sink
+const DEFAULT_NEO4J_DIR = "neo4j-import"
+
+// SinkOptions configure the sinks of the import
+type SinkOptions struct {
+	// Output is a file ("-" for stdout) for cypher, a directory for neo4j-csv, the database file for sqlite
+	// and the connection URL for postgres; the arango sink writes to the database of the connection flags instead
+	Output string
+	// DDL is the file the SQL sinks write their generated schema to, if any
+	DDL string
+	// EnsureSchema makes the arango sink create the database, collections and graph that are missing
+	EnsureSchema bool
+	Schema       SchemaFlags
+}
+
+// openSink checks the sink and opens its output, before anything is read, and returns the function that writes the graph to it
+func openSink(name string, opts SinkOptions, cf ConnectionFlags) func(g *Graph) {
+	output := opts.Output
+	switch strings.ToLower(name) {
+	case SINK_ARANGO:
+		return func(g *Graph) {
+			var db driver.Database
+			if opts.EnsureSchema {
+				db = ensureSchema(cf, opts.Schema)
+			} else {
+				db = cf.Open()
+			}
+			writeGraph(db, g)
+		}
+	case SINK_CYPHER:
+		out := openOutput(output)
+		return func(g *Graph) {
+			defer out.Close()
+			if err := writeCypher(out, g); err != nil {
+				fmt.Fprintln(logOutput, "Error writing the Cypher statements:", err.Error())
+				panic(err.Error())
+			}
+		}
+	case SINK_NEO4J_CSV:
+		if output == "" || output == "-" {
+			output = DEFAULT_NEO4J_DIR
+		}
+		return func(g *Graph) {
+			if err := writeNeo4jCSV(output, g); err != nil {
+				fmt.Fprintln(logOutput, "Error writing the neo4j-admin import files:", err.Error())
+				panic(err.Error())
+			}
+		}
+	case SINK_SQLITE, SINK_POSTGRES:
+		dialect := sqlDialects[strings.ToLower(name)]
+		if output == "" || output == "-" {
+			output = DEFAULT_SQLITE_FILE
+			if dialect.Name == SQL_DIALECT_POSTGRES {
+				output = DEFAULT_POSTGRES_URL
+			}
+		}
+		if opts.DDL != "" {
+			out := openOutput(opts.DDL)
+			if err := writeDDL(out, dialect); err != nil {
+				fmt.Fprintln(logOutput, "Error writing the schema:", err.Error())
+				panic(err.Error())
+			}
+			out.Close()
+		}
+		return func(g *Graph) {
+			fmt.Fprintf(logOutput, "Connecting to the %s database\n", dialect.Name)
+			db, err := openSQL(dialect, output)
+			if err != nil {
+				fmt.Fprintln(logOutput, "Error connecting to the database:", err.Error())
+				panic(err.Error())
+			}
+			defer db.Close()
+			if err := writeSQL(db, dialect, g); err != nil {
+				fmt.Fprintln(logOutput, "Error writing the graph:", err.Error())
+				panic(err.Error())
+			}
+		}
+	default:
+		err := fmt.Errorf("unknown sink %q (expected one of: %s)", name, strings.Join(sinkNames, ", "))
+		fmt.Fprintln(logOutput, "Error in --sink:", err.Error())
+		panic(err.Error())
+	}
+}
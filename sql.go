@@ -0,0 +1,271 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const SQL_DIALECT_SQLITE = "sqlite"
+const SQL_DIALECT_POSTGRES = "postgres"
+
+const DEFAULT_SQLITE_FILE = "canonical_debate.db"
+const DEFAULT_POSTGRES_URL = "postgres://localhost/canonical_debate?sslmode=disable"
+
+// A sqlDialect holds what differs between the databases: the driver, the column types and the placeholders
+type sqlDialect struct {
+	Name        string
+	Driver      string
+	Types       map[reflect.Type]string
+	Placeholder func(n int) string
+}
+
+var sqlDialects = map[string]sqlDialect{
+	SQL_DIALECT_SQLITE: {
+		Name:   SQL_DIALECT_SQLITE,
+		Driver: "sqlite3",
+		Types: map[reflect.Type]string{
+			reflect.TypeOf(""):          "TEXT",
+			reflect.TypeOf(false):       "BOOLEAN",
+			reflect.TypeOf(0):           "INTEGER",
+			reflect.TypeOf(float32(0)):  "REAL",
+			reflect.TypeOf(time.Time{}): "TIMESTAMP",
+			reflect.TypeOf([]string{}):  "TEXT",
+		},
+		Placeholder: func(n int) string { return "?" },
+	},
+	SQL_DIALECT_POSTGRES: {
+		Name:   SQL_DIALECT_POSTGRES,
+		Driver: "postgres",
+		Types: map[reflect.Type]string{
+			reflect.TypeOf(""):          "TEXT",
+			reflect.TypeOf(false):       "BOOLEAN",
+			reflect.TypeOf(0):           "INTEGER",
+			reflect.TypeOf(float32(0)):  "REAL",
+			reflect.TypeOf(time.Time{}): "TIMESTAMP WITH TIME ZONE",
+			reflect.TypeOf([]string{}):  "TEXT",
+		},
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	},
+}
+
+// A sqlTable is a collection of the graph. Its columns are the JSON fields of the document type, under the same names;
+// lists of strings (the terms of a title) are stored separated by spaces.
+// An edge also gets a foreign key column for each collection its ends can be in (e.g. "_from_claims" and
+// "_from_arguments" for an inference), holding the _key of the vertex; "_from" and "_to" keep the document handles.
+type sqlTable struct {
+	Name string
+	Type reflect.Type
+	From []string
+	To   []string
+}
+
+var sqlTables = []sqlTable{
+	{Name: "claims", Type: reflect.TypeOf(Claim{})},
+	{Name: "arguments", Type: reflect.TypeOf(Argument{})},
+	{Name: "ratings", Type: reflect.TypeOf(Rating{})},
+	{Name: "users", Type: reflect.TypeOf(User{})},
+	{Name: "inferences", Type: reflect.TypeOf(Inference{}), From: []string{"claims", "arguments"}, To: []string{"arguments"}},
+	{Name: "base_claims", Type: reflect.TypeOf(BaseClaim{}), From: []string{"arguments"}, To: []string{"claims"}},
+	{Name: "premises", Type: reflect.TypeOf(Premise{}), From: []string{"claims"}, To: []string{"claims"}},
+	{Name: "rating_targets", Type: reflect.TypeOf(RatingTarget{}), From: []string{"ratings"}, To: []string{"claims", "arguments"}},
+	{Name: "created", Type: reflect.TypeOf(Creation{}), From: []string{"users"}, To: []string{"claims", "arguments", "ratings"}},
+}
+
+// A sqlColumn is a field of the document type, or one of the foreign keys of an edge
+type sqlColumn struct {
+	Name       string
+	Field      int
+	Type       reflect.Type
+	Nullable   bool
+	References string
+	End        string
+}
+
+func (t sqlTable) Columns() []sqlColumn {
+	columns := []sqlColumn{}
+	for i := 0; i < t.Type.NumField(); i++ {
+		field := t.Type.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		column := sqlColumn{Name: name, Field: i, Type: field.Type}
+		if field.Type.Kind() == reflect.Ptr {
+			column.Type = field.Type.Elem()
+			column.Nullable = true
+		}
+		columns = append(columns, column)
+	}
+	for _, end := range []struct {
+		Name   string
+		Tables []string
+	}{{"_from", t.From}, {"_to", t.To}} {
+		for _, table := range end.Tables {
+			columns = append(columns, sqlColumn{
+				Name:       end.Name + "_" + table,
+				Field:      -1,
+				Type:       reflect.TypeOf(""),
+				Nullable:   len(end.Tables) > 1,
+				References: table,
+				End:        end.Name,
+			})
+		}
+	}
+	return columns
+}
+
+// Values returns the values of the columns for a document of the table
+func (t sqlTable) Values(doc interface{}) []interface{} {
+	v := reflect.ValueOf(doc)
+	values := []interface{}{}
+	for _, column := range t.Columns() {
+		if column.Field >= 0 {
+			field := v.Field(column.Field)
+			if terms, ok := field.Interface().([]string); ok {
+				values = append(values, strings.Join(terms, " "))
+			} else if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					values = append(values, nil)
+				} else {
+					values = append(values, field.Elem().Interface())
+				}
+			} else {
+				values = append(values, field.Interface())
+			}
+			continue
+		}
+		// Foreign key: the _key of the vertex, when it is in the referenced collection
+		handle := v.FieldByName("From").String()
+		if column.End == "_to" {
+			handle = v.FieldByName("To").String()
+		}
+		if strings.HasPrefix(handle, column.References+"/") {
+			values = append(values, strings.TrimPrefix(handle, column.References+"/"))
+		} else {
+			values = append(values, nil)
+		}
+	}
+	return values
+}
+
+func sqlQuote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// sqlDDL generates the statements creating the tables, vertices first so the foreign keys can refer to them
+func sqlDDL(dialect sqlDialect) []string {
+	statements := []string{}
+	for _, table := range sqlTables {
+		lines := []string{}
+		references := []string{}
+		for _, column := range table.Columns() {
+			line := "  " + sqlQuote(column.Name) + " " + dialect.Types[column.Type]
+			if column.Name == "_key" {
+				line += " PRIMARY KEY"
+			} else if !column.Nullable {
+				line += " NOT NULL"
+			}
+			lines = append(lines, line)
+			if column.References != "" {
+				references = append(references, fmt.Sprintf("  FOREIGN KEY (%s) REFERENCES %s (%s)", sqlQuote(column.Name), sqlQuote(column.References), sqlQuote("_key")))
+			}
+		}
+		statements = append(statements, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n)", sqlQuote(table.Name), strings.Join(append(lines, references...), ",\n")))
+		for _, column := range table.Columns() {
+			if column.References != "" {
+				statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+					sqlQuote(table.Name+"_"+column.Name), sqlQuote(table.Name), sqlQuote(column.Name)))
+			}
+		}
+		for _, idx := range graphIndexes {
+			if idx.Collection != table.Name {
+				continue
+			}
+			columns := []string{}
+			for _, field := range idx.Fields {
+				columns = append(columns, sqlQuote(field))
+			}
+			unique := ""
+			if idx.Unique {
+				unique = "UNIQUE "
+			}
+			statements = append(statements, fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)",
+				unique, sqlQuote(idx.Name()), sqlQuote(table.Name), strings.Join(columns, ", ")))
+		}
+	}
+	return statements
+}
+
+// writeDDL writes the generated schema as a script
+func writeDDL(w io.Writer, dialect sqlDialect) error {
+	_, err := fmt.Fprintf(w, "-- Canonical Debate schema for %s, generated by arango-importer\n\n%s;\n", dialect.Name, strings.Join(sqlDDL(dialect), ";\n\n"))
+	return err
+}
+
+// openSQL connects to the SQLite file or PostgreSQL URL
+func openSQL(dialect sqlDialect, dsn string) (*sql.DB, error) {
+	if dialect.Name == SQL_DIALECT_SQLITE {
+		dsn = "file:" + dsn + "?_foreign_keys=on"
+	}
+	db, err := sql.Open(dialect.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// writeSQL creates the tables if needed, and replaces their contents with the graph, in a single transaction
+func writeSQL(db *sql.DB, dialect sqlDialect, g *Graph) error {
+	for _, statement := range sqlDDL(dialect) {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("%s\n%s", err.Error(), statement)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Edges go first, so no foreign key is left dangling
+	for i := len(sqlTables) - 1; i >= 0; i-- {
+		if _, err := tx.Exec("DELETE FROM " + sqlQuote(sqlTables[i].Name)); err != nil {
+			return err
+		}
+	}
+
+	docs := g.Documents()
+	for _, table := range sqlTables {
+		names := []string{}
+		placeholders := []string{}
+		for i, column := range table.Columns() {
+			names = append(names, sqlQuote(column.Name))
+			placeholders = append(placeholders, dialect.Placeholder(i+1))
+		}
+		stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", sqlQuote(table.Name), strings.Join(names, ", "), strings.Join(placeholders, ", ")))
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs[table.Name] {
+			if _, err := stmt.Exec(table.Values(doc)...); err != nil {
+				stmt.Close()
+				return fmt.Errorf("error inserting into %s: %s\nItem: %+v", table.Name, err.Error(), doc)
+			}
+		}
+		stmt.Close()
+		fmt.Fprintf(logOutput, "Inserted %d rows into %s\n", len(docs[table.Name]), table.Name)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestSQLDDL(t *testing.T) {
+	statements := strings.Join(sqlDDL(sqlDialects[SQL_DIALECT_SQLITE]), ";\n")
+	expected := []string{
+		`CREATE TABLE IF NOT EXISTS "claims" (`,
+		`"_key" TEXT PRIMARY KEY`,
+		`"truth" REAL NOT NULL`,
+		// An inference comes from a claim or an argument, so either foreign key can be NULL
+		`"_from_claims" TEXT,`,
+		`"_from_arguments" TEXT,`,
+		`"_to_arguments" TEXT NOT NULL`,
+		`FOREIGN KEY ("_from_claims") REFERENCES "claims" ("_key")`,
+		`CREATE INDEX IF NOT EXISTS "inferences__from_claims" ON "inferences" ("_from_claims")`,
+	}
+	for _, statement := range expected {
+		if !strings.Contains(statements, statement) {
+			t.Errorf("the schema has no %s", statement)
+		}
+	}
+	// The vertices are created before the edges referring to them
+	if strings.Index(statements, `TABLE IF NOT EXISTS "arguments"`) > strings.Index(statements, `TABLE IF NOT EXISTS "inferences"`) {
+		t.Errorf("the inferences are created before the arguments")
+	}
+}
+
+func TestSQLValues(t *testing.T) {
+	g := testGraph(t)
+	var inferences sqlTable
+	for _, table := range sqlTables {
+		if table.Name == "inferences" {
+			inferences = table
+		}
+	}
+	columns := map[string]int{}
+	for i, column := range inferences.Columns() {
+		columns[column.Name] = i
+	}
+	for _, inference := range g.Inferences {
+		values := inferences.Values(inference)
+		// The inferences of the test graph come from R, a claim
+		if values[columns["_from_claims"]] != strings.TrimPrefix(inference.From, "claims/") || values[columns["_from_arguments"]] != nil {
+			t.Errorf("inference %s from %s has the keys %v and %v", inference.Key, inference.From, values[columns["_from_claims"]], values[columns["_from_arguments"]])
+		}
+		if values[columns["_from"]] != inference.From || values[columns["_to_arguments"]] != strings.TrimPrefix(inference.To, "arguments/") {
+			t.Errorf("inference %s has the values %v", inference.Key, values)
+		}
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+	count := 0
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		t.Fatalf("%s: %s", query, err.Error())
+	}
+	return count
+}
+
+func TestWriteSQL(t *testing.T) {
+	dialect := sqlDialects[SQL_DIALECT_SQLITE]
+	db, err := openSQL(dialect, ":memory:")
+	if err != nil {
+		t.Fatalf("openSQL: %s", err.Error())
+	}
+	defer db.Close()
+	// Every connection to :memory: has a database of its own
+	db.SetMaxOpenConns(1)
+
+	g := testGraph(t)
+	if err := writeSQL(db, dialect, g); err != nil {
+		t.Fatalf("writeSQL: %s", err.Error())
+	}
+	for name, docs := range g.Documents() {
+		if count := countRows(t, db, "SELECT COUNT(*) FROM "+sqlQuote(name)); count != len(docs) {
+			t.Errorf("%s has %d rows, expected %d", name, count, len(docs))
+		}
+	}
+	// Each foreign key is set when the end is in its table, and NULL otherwise
+	if count := countRows(t, db, `SELECT COUNT(*) FROM "inferences" WHERE "_from_claims" IS NOT NULL AND "_from_arguments" IS NULL
+		AND "_from" = 'claims/' || "_from_claims"`); count != len(g.Inferences) {
+		t.Errorf("%d of the %d inferences have the key of their claim only", count, len(g.Inferences))
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM "created" WHERE ("_to_claims" IS NULL) + ("_to_arguments" IS NULL) + ("_to_ratings" IS NULL) = 2`); count != len(g.Creations) {
+		t.Errorf("%d of the %d created edges have exactly one key", count, len(g.Creations))
+	}
+
+	// Importing again replaces the rows
+	other := buildGraph(dedupRoot())
+	if err := writeSQL(db, dialect, other); err != nil {
+		t.Fatalf("writeSQL again: %s", err.Error())
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM "claims"`); count != len(other.Claims) {
+		t.Errorf("claims has %d rows after the second import, expected %d", count, len(other.Claims))
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM "users"`); count != len(other.Users) {
+		t.Errorf("users has %d rows after the second import, expected %d", count, len(other.Users))
+	}
+	if count := countRows(t, db, `SELECT COUNT(*) FROM "claims" WHERE "id" = 'P1'`); count != 0 {
+		t.Errorf("the claim P1 of the first import is still there")
+	}
+}
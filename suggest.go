@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const SUGGEST_FORMAT_TEXT = "text"
+const SUGGEST_FORMAT_CSV = "csv"
+
+// A MergeSuggestion is a pair of claims with similar titles, for a curator to review
+type MergeSuggestion struct {
+	Score float64
+	A     Claim
+	B     Claim
+}
+
+// titleVectors weighs the words of the normalized titles by TF-IDF, scaled to unit length,
+// so that the similarity of two titles is the dot product of their vectors (the cosine).
+// Words found in every title weigh nothing.
+func titleVectors(claims []Claim) []map[string]float64 {
+	counts := make([]map[string]float64, len(claims))
+	df := map[string]int{}
+	for i, claim := range claims {
+		counts[i] = map[string]float64{}
+		for _, word := range strings.Fields(normalizeText(claim.Title)) {
+			counts[i][word]++
+		}
+		for word := range counts[i] {
+			df[word]++
+		}
+	}
+
+	vectors := make([]map[string]float64, len(claims))
+	for i, tf := range counts {
+		vectors[i] = map[string]float64{}
+		length := 0.0
+		for word, count := range tf {
+			weight := count * math.Log(float64(len(claims))/float64(df[word]))
+			if weight > 0 {
+				vectors[i][word] = weight
+				length += weight * weight
+			}
+		}
+		for word := range vectors[i] {
+			vectors[i][word] /= math.Sqrt(length)
+		}
+	}
+	return vectors
+}
+
+// suggestMerges finds the pairs of mergeable claims whose titles have a cosine similarity of at least threshold,
+// most similar first. Claims with the same normalized title are left out, as --dedup normalized merges them.
+func suggestMerges(g *Graph, threshold float64) []MergeSuggestion {
+	claims := []Claim{}
+	for _, claim := range g.Claims {
+		if mergeable(claim) {
+			claims = append(claims, claim)
+		}
+	}
+	vectors := titleVectors(claims)
+
+	// Only the claims sharing a word have a score, so they are found through the claims of each word
+	postings := map[string][]int{}
+	for i, vector := range vectors {
+		for word := range vector {
+			postings[word] = append(postings[word], i)
+		}
+	}
+
+	suggestions := []MergeSuggestion{}
+	scores := make([]float64, len(claims))
+	for i, vector := range vectors {
+		candidates := []int{}
+		for word, weight := range vector {
+			for _, j := range postings[word] {
+				if j <= i {
+					continue
+				}
+				if scores[j] == 0 {
+					candidates = append(candidates, j)
+				}
+				scores[j] += weight * vectors[j][word]
+			}
+		}
+		for _, j := range candidates {
+			score := scores[j]
+			scores[j] = 0
+			if score >= threshold && normalizeText(claims[i].Title) != normalizeText(claims[j].Title) {
+				suggestions = append(suggestions, MergeSuggestion{Score: math.Min(score, 1), A: claims[i], B: claims[j]})
+			}
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].A.ID != suggestions[j].A.ID {
+			return suggestions[i].A.ID < suggestions[j].A.ID
+		}
+		return suggestions[i].B.ID < suggestions[j].B.ID
+	})
+	return suggestions
+}
+
+// documentLink is the page of a document in the ArangoDB web interface, where the import writes it
+func documentLink(cf ConnectionFlags, handle string) string {
+	return strings.TrimSuffix(cf.Server, "/") + "/_db/" + cf.DBName + "/_admin/aardvark/index.html#collection/" + handle
+}
+
+// runSuggestMerges lists the pairs of claims that may say the same thing, for curators to merge by hand:
+//
+//	go run *.go suggest-merges -f data/Backup_Nodes_20190819.json --threshold 0.6 --format csv -o merges.csv
+//
+// Nothing is merged or written to the database.
+func runSuggestMerges(args []string) {
+	fs := flag.NewFlagSet("suggest-merges", flag.ExitOnError)
+	source := GraphSourceFlags{}
+	source.Register(fs)
+	var threshold float64
+	var limit int
+	var format, output string
+	fs.Float64Var(&threshold, "threshold", 0.5, "lowest similarity of the titles to suggest, from 0 to 1")
+	fs.IntVar(&limit, "limit", 50, "number of pairs to list, most similar first (0 for all)")
+	fs.StringVar(&format, "format", SUGGEST_FORMAT_TEXT, "output format ("+SUGGEST_FORMAT_TEXT+", "+SUGGEST_FORMAT_CSV+")")
+	fs.StringVar(&output, "o", "-", "output file (\"-\" for stdout)")
+	fs.Parse(args)
+
+	if format != SUGGEST_FORMAT_TEXT && format != SUGGEST_FORMAT_CSV {
+		err := fmt.Errorf("unknown format %q (expected one of: %s, %s)", format, SUGGEST_FORMAT_TEXT, SUGGEST_FORMAT_CSV)
+		fmt.Fprintln(logOutput, "Error in --format:", err.Error())
+		panic(err.Error())
+	}
+
+	out := openOutput(output)
+	defer out.Close()
+
+	g := source.Load()
+	suggestions := suggestMerges(g, threshold)
+	fmt.Fprintf(logOutput, "Found %d pairs of similar claims\n", len(suggestions))
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	var err error
+	if format == SUGGEST_FORMAT_CSV {
+		err = writeMergeSuggestionsCSV(out, g, source.Connection, suggestions)
+	} else {
+		err = writeMergeSuggestions(out, g, source.Connection, suggestions)
+	}
+	if err != nil {
+		fmt.Fprintln(logOutput, "Error writing the suggestions:", err.Error())
+		panic(err.Error())
+	}
+	fmt.Fprintln(logOutput, "Done.")
+}
+
+// debateTitles returns the title of each debate root, which is usually a claim
+func debateTitles(g *Graph) map[string]string {
+	titles := map[string]string{}
+	for _, arg := range g.Arguments {
+		titles[arg.ArangoID()] = arg.Title
+	}
+	for _, claim := range g.Claims {
+		titles[claim.ArangoID()] = claim.Title
+	}
+	return titles
+}
+
+func writeMergeSuggestions(w io.Writer, g *Graph, cf ConnectionFlags, suggestions []MergeSuggestion) error {
+	roots := g.DebateRoots()
+	titles := debateTitles(g)
+	for i, suggestion := range suggestions {
+		if _, err := fmt.Fprintf(w, "%d. similarity %.3f\n", i+1, suggestion.Score); err != nil {
+			return err
+		}
+		for _, claim := range []Claim{suggestion.A, suggestion.B} {
+			root := roots[claim.ArangoID()]
+			if _, err := fmt.Fprintf(w, "   %s %q\n      debate %q %s\n", claim.ID, claim.Title, titles[root], documentLink(cf, root)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeMergeSuggestionsCSV(w io.Writer, g *Graph, cf ConnectionFlags, suggestions []MergeSuggestion) error {
+	roots := g.DebateRoots()
+	titles := debateTitles(g)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"rank", "similarity", "id_a", "title_a", "debate_a", "link_a", "id_b", "title_b", "debate_b", "link_b"})
+	for i, suggestion := range suggestions {
+		record := []string{strconv.Itoa(i + 1), strconv.FormatFloat(suggestion.Score, 'f', 3, 64)}
+		for _, claim := range []Claim{suggestion.A, suggestion.B} {
+			root := roots[claim.ArangoID()]
+			record = append(record, claim.ID, claim.Title, titles[root], documentLink(cf, root))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func suggestGraph() *Graph {
+	g := &Graph{}
+	for _, claim := range []struct {
+		ID    string
+		Title string
+	}{
+		{"a", "Nuclear power plants emit less carbon dioxide than coal plants."},
+		{"b", "Nuclear power plants emit much less carbon dioxide than coal power plants."},
+		// The same title as a once normalized, which --dedup normalized merges instead
+		{"d", "nuclear power plants emit less carbon dioxide than coal plants!"},
+		{"c", "The pension reform hurts the poor in Brazil."},
+		{"f", "Brazil should invest in public schools."},
+	} {
+		g.Claims = append(g.Claims, NewClaim(DebateMapNode{ID: claim.ID, Current: Current{Title: TitleSet{Base: claim.Title}}}))
+	}
+	// A multi-premise claim can't be merged, whatever its title
+	mp := NewClaim(DebateMapNode{ID: "m" + MP_CLAIM_ID_SUFFIX, Current: Current{Title: TitleSet{Base: "Nuclear power plants emit less carbon dioxide than coal plants."}}})
+	mp.MultiPremise = true
+	g.Claims = append(g.Claims, mp)
+	return g
+}
+
+func TestTitleVectors(t *testing.T) {
+	g := suggestGraph()
+	vectors := titleVectors(g.Claims)
+	for i, vector := range vectors {
+		length := 0.0
+		for _, weight := range vector {
+			length += weight * weight
+		}
+		if math.Abs(length-1) > 1e-9 {
+			t.Errorf("the vector of %s has the length %v", g.Claims[i].ID, math.Sqrt(length))
+		}
+	}
+	// A word in every title weighs nothing
+	claims := []Claim{g.Claims[0], g.Claims[1]}
+	for i, vector := range titleVectors(claims) {
+		if _, ok := vector["nuclear"]; ok {
+			t.Errorf("the vector of %s weighs a word of every title: %v", claims[i].ID, vector)
+		}
+	}
+}
+
+func TestSuggestMerges(t *testing.T) {
+	g := suggestGraph()
+	pairs := func(suggestions []MergeSuggestion) map[string]float64 {
+		m := map[string]float64{}
+		for _, suggestion := range suggestions {
+			m[suggestion.A.ID+"-"+suggestion.B.ID] = suggestion.Score
+		}
+		return m
+	}
+
+	// The near-duplicates are suggested, most similar first, but not the claims that are the same once normalized
+	suggestions := suggestMerges(g, 0.5)
+	found := pairs(suggestions)
+	if len(found) != 2 || found["a-b"] < 0.5 || found["a-b"] > 1 || math.Abs(found["b-d"]-found["a-b"]) > 1e-9 {
+		t.Errorf("suggested %v, expected a-b and b-d", found)
+	}
+	for i := 1; i < len(suggestions); i++ {
+		if suggestions[i].Score > suggestions[i-1].Score {
+			t.Errorf("the suggestions are not sorted by similarity: %v", found)
+		}
+	}
+
+	// Claims sharing a word are only similar under a low threshold
+	found = pairs(suggestMerges(g, 0.01))
+	if found["c-f"] == 0 || found["c-f"] >= 0.5 {
+		t.Errorf("the claims about Brazil have the similarity %v, expected a low one", found["c-f"])
+	}
+	if _, ok := found["a-c"]; ok {
+		t.Errorf("claims without a word in common are suggested: %v", found)
+	}
+
+	// Above the similarity of the near-duplicates, nothing is suggested
+	if suggestions := suggestMerges(g, found["a-b"]+0.01); len(suggestions) != 0 {
+		t.Errorf("suggested %v above the threshold %v", pairs(suggestions), found["a-b"]+0.01)
+	}
+}
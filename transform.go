@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+)
+
+// buildGraph converts the Debate Map nodes into the Claims, Arguments and edges of the Canonical Debate graph.
+// Nothing is written to the database here, so the same graph can be sent to the database or exported.
+func buildGraph(general DebateMapRoot) *Graph {
+	g := &Graph{}
+	data := general.Nodes
+	revisions := map[string]NodeRevision{}
+	maps := map[string]DebateMapMap{}
+	for _, rev := range general.NodeRevisions {
+		revisions[rev.ID] = rev
+	}
+	for _, dmm := range general.Maps {
+		maps[dmm.RootNode] = dmm
+	}
+
+	// The documents are kept in the order they were created
+	claims := make(map[string]Claim)
+	args := make(map[string]Argument)
+	claimOrder := []string{}
+	argOrder := []string{}
+	interveningArgs := []Argument{}
+	addClaim := func(claim Claim) {
+		if _, ok := claims[claim.ID]; !ok {
+			claimOrder = append(claimOrder, claim.ID)
+		}
+		claims[claim.ID] = claim
+	}
+	addArgument := func(arg Argument) {
+		if _, ok := args[arg.ID]; !ok {
+			argOrder = append(argOrder, arg.ID)
+		}
+		args[arg.ID] = arg
+	}
+
+	// First pass: create Claims and Arguments
+	newClaims := []DebateMapNode{}
+	for i, node := range data {
+		if node.ID == "" {
+			node.ID = node.Current.ID
+			data[i] = node
+		}
+		if rev, ok := revisions[node.CurrentRevision]; ok {
+			node.Current.Title = rev.Title
+			if rev.ArgumentType != 0 {
+				node.Current.ArgumentType = rev.ArgumentType
+			}
+		}
+		fmt.Fprintf(logOutput, "Read node: %+v\n", node)
+		switch node.Type {
+		case NODE_TYPE_CLAIM:
+			claim := NewClaim(node)
+			addClaim(claim)
+		case NODE_TYPE_ARGUMENT:
+			if node.MultiPremise {
+				// In Debate Map, it's the Arguments that are MP
+				// In this graph, it will be an MP Claim instead, which needs to be created
+				if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
+					fmt.Fprintln(logOutput, "----------------------------L0Wv33MFQiuWVbWEKcELsA is MPClaim")
+				}
+
+				// Replace the new node with claim and arg nodes
+				argNode, claimNode := node.ConvertToMPClaim()
+				data[i] = argNode
+				newClaims = append(newClaims, claimNode)
+				if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
+					fmt.Fprintln(logOutput, "----------------------------For MPClaim L0Wv33MFQiuWVbWEKcELsA created claimNode", claimNode.ID)
+					fmt.Fprintln(logOutput, "----------------------------For MPClaim L0Wv33MFQiuWVbWEKcELsA created argNode", argNode.ID)
+				}
+
+				claim := NewClaim(claimNode)
+				addClaim(claim)
+
+				argument := NewArgument(argNode)
+				argument.ClaimID = claim.ID
+				addArgument(argument)
+			} else {
+				argument := NewArgument(node)
+				if argument.ID == "L0Wv33MFQiuWVbWEKcELsA" {
+					fmt.Fprintln(logOutput, "----------------------------Added L0Wv33MFQiuWVbWEKcELsA to args")
+				}
+				addArgument(argument)
+			}
+		case NODE_TYPE_CATEGORY, NODE_TYPE_PACKAGE, NODE_TYPE_QUESTION:
+			// Just to capture node information, these "debate" placeholders will be converted into
+			// a claim and (if there's a parent node) an argument
+			// They will require manual curation later to make them match the CD concepts
+			if dmm, ok := maps[node.ID]; ok {
+				node.Current.Title.Base = dmm.Name
+			}
+			argNode, claimNode := node.ConvertToClaimAndArg()
+			if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
+				fmt.Fprintln(logOutput, "----------------------------L0Wv33MFQiuWVbWEKcELsA is a category, package or question")
+				fmt.Fprintln(logOutput, "----------------------------For L0Wv33MFQiuWVbWEKcELsA created claimNode", claimNode.ID)
+			}
+
+			data[i] = claimNode
+
+			claim := NewClaim(claimNode)
+			addClaim(claim)
+
+			if argNode != nil {
+				data[i] = *argNode
+				newClaims = append(newClaims, claimNode)
+				if node.ID == "L0Wv33MFQiuWVbWEKcELsA" {
+					fmt.Fprintln(logOutput, "----------------------------For L0Wv33MFQiuWVbWEKcELsA added claimNodeto newClaims")
+					fmt.Fprintln(logOutput, "----------------------------For L0Wv33MFQiuWVbWEKcELsA created argNode", argNode.ID)
+				}
+
+				argument := NewArgument(*argNode)
+				argument.ClaimID = claim.ID
+				addArgument(argument)
+			}
+		}
+	}
+	data = append(data, newClaims...)
+
+	// Second pass: create edges
+	for _, node := range data {
+		fmt.Fprintf(logOutput, "Read item for edges: %+v\n", node)
+		switch node.Type {
+		case NODE_TYPE_CLAIM:
+			nodeClaim, ok := claims[node.ID]
+			if !ok {
+				panic(fmt.Sprintf("Node claim %s not found", node.ID))
+			}
+			if node.MultiPremise {
+				if len(node.Children) == 0 {
+					fmt.Fprintln(logOutput, "----------------------------MPClaim has no children")
+				}
+				for key, childVal := range node.Children {
+					child := NewChildFromData(key, childVal)
+					if child != nil {
+						if claim, ok := claims[child.ID]; ok {
+							g.Premises = append(g.Premises, NewPremise(nodeClaim.ArangoID(), claim, node.ChildOrder(child.ID)))
+						} else {
+							panic(fmt.Sprintf("Child Premise %s not found", child.ID))
+						}
+					} else {
+						fmt.Fprintln(logOutput, "----------------------------Premise child from data is nil")
+					}
+				}
+			} else {
+				if len(node.Children) == 0 {
+					fmt.Fprintln(logOutput, "----------------------------Claim has no children")
+				}
+				for key, childVal := range node.Children {
+					child := NewChildFromData(key, childVal)
+					if child != nil {
+						id := nodeClaim.ID
+						if arg, ok := args[child.ID]; ok {
+							arg.TargetClaimID = &id
+							arg.Pro = child.IsPro()
+							args[child.ID] = arg
+							g.Inferences = append(g.Inferences, NewInference(nodeClaim.ArangoID(), arg))
+						} else if claim, ok := claims[child.ID]; ok {
+							// Data consistency problem in the Debate Map version!
+							// Create an intervening Argument to resolve the problem.
+							// The claim can be under several claims, so the ID of the argument is that of the pair.
+							argID := nodeClaim.ID + INTERVENING_ARGUMENT_ID_SEPARATOR + claim.ID
+							arg := Argument{
+								Key:           documentKey("arguments", argID),
+								ID:            argID,
+								TargetClaimID: &id,
+								ClaimID:       claim.ID,
+								CreatedAt:     claim.CreatedAt,
+								Creator:       claim.Creator,
+								Pro:           child.IsPro(),
+								Relevance:     1.00,
+								Str:           0.50,
+							}
+							interveningArgs = append(interveningArgs, arg)
+							g.Inferences = append(g.Inferences, NewInference(nodeClaim.ArangoID(), arg))
+							g.BaseClaims = append(g.BaseClaims, NewBaseClaim(arg, claim.ArangoID()))
+						} else {
+							panic(fmt.Sprintf("Child Argument %s not found", child.ID))
+						}
+					} else {
+						fmt.Fprintln(logOutput, "----------------------------Claim child from data is nil")
+					}
+				}
+			}
+		case NODE_TYPE_ARGUMENT:
+			nodeArg, ok := args[node.ID]
+			if !ok {
+				panic(fmt.Sprintf("Node argument %s not found", node.ID))
+			}
+			if len(node.Children) == 0 {
+				fmt.Fprintln(logOutput, "----------------------------Argument has no children")
+			}
+			for key, childVal := range node.Children {
+				child := NewChildFromData(key, childVal)
+				if child != nil {
+					id := nodeArg.ID
+					if arg, ok := args[child.ID]; ok {
+						arg.TargetArgumentID = &id
+						arg.Pro = child.IsPro()
+						args[child.ID] = arg
+						g.Inferences = append(g.Inferences, NewInference(nodeArg.ArangoID(), arg))
+					} else if claim, ok := claims[child.ID]; ok {
+						nodeArg.ClaimID = claim.ID
+						args[node.ID] = nodeArg
+						g.BaseClaims = append(g.BaseClaims, NewBaseClaim(nodeArg, claim.ArangoID()))
+					} else {
+						panic(fmt.Sprintf("Child %s not found", child.ID))
+					}
+				} else {
+					fmt.Fprintln(logOutput, "----------------------------Argument child from data is nil")
+				}
+			}
+		}
+	}
+
+	for _, id := range claimOrder {
+		g.Claims = append(g.Claims, claims[id])
+	}
+	for _, id := range argOrder {
+		g.Arguments = append(g.Arguments, args[id])
+	}
+	g.Arguments = append(g.Arguments, interveningArgs...)
+	addRatings(g, general.NodeRatings)
+	addUsers(g, general.Users, general.UserExtras)
+	detectLanguages(g)
+	fmt.Fprintf(logOutput, "Built %d claims, %d arguments, %d inferences, %d base claims, %d premises, %d ratings and %d users\n",
+		len(g.Claims), len(g.Arguments), len(g.Inferences), len(g.BaseClaims), len(g.Premises), len(g.Ratings), len(g.Users))
+	return g
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func claimNode(id, title string, children map[string]interface{}) DebateMapNode {
+	return DebateMapNode{
+		ID:       id,
+		Type:     NODE_TYPE_CLAIM,
+		Current:  Current{ID: id, Title: TitleSet{Base: title}},
+		Parents:  map[string]interface{}{},
+		Children: children,
+	}
+}
+
+// A claim directly under two claims gets an intervening argument under each, with an ID of its own
+func TestBuildGraphSharedChildClaim(t *testing.T) {
+	root := DebateMapRoot{Nodes: []DebateMapNode{
+		claimNode("B", "Nuclear is clean.", map[string]interface{}{
+			"A": map[string]interface{}{"polarity": float64(ARGUMENT_POLARITY_PRO)},
+		}),
+		claimNode("C", "Nuclear is dangerous.", map[string]interface{}{
+			"A": map[string]interface{}{"polarity": float64(ARGUMENT_POLARITY_CON)},
+		}),
+		claimNode("A", "Nuclear emits little CO2.", map[string]interface{}{}),
+	}}
+
+	g := buildGraph(root)
+	if err := checkUniqueIndexes(g); err != nil {
+		t.Fatalf("checkUniqueIndexes: %s", err.Error())
+	}
+	if err := validateGraph(g); err != nil {
+		t.Fatalf("validateGraph: %s", err.Error())
+	}
+	if len(g.Arguments) != 2 {
+		t.Fatalf("built %d arguments, expected 2", len(g.Arguments))
+	}
+
+	expected := map[string]bool{"B->A": true, "C->A": false}
+	for _, arg := range g.Arguments {
+		pro, ok := expected[arg.ID]
+		if !ok {
+			t.Errorf("unexpected argument ID %q", arg.ID)
+			continue
+		}
+		delete(expected, arg.ID)
+		if arg.Pro != pro || arg.ClaimID != "A" || arg.TargetClaimID == nil || *arg.TargetClaimID != arg.ID[:1] {
+			t.Errorf("unexpected intervening argument: %+v", arg)
+		}
+		if arg.Key != documentKey("arguments", arg.ID) {
+			t.Errorf("argument %s has the key %s, expected the key of its ID", arg.ID, arg.Key)
+		}
+	}
+	if len(expected) > 0 {
+		t.Errorf("missing intervening arguments: %v", expected)
+	}
+}
+
+func TestTermSet(t *testing.T) {
+	tests := []struct {
+		JSON     string
+		Expected TermSet
+	}{
+		{`{"reform": true, "brasil": true, "_key": "allTerms", "old": false}`, TermSet{"brasil", "reform"}},
+		{`{"_key": "allTerms"}`, TermSet{}},
+		// Terms that are all numbers, exported as an array
+		{`[null, true, null, true]`, TermSet{"1", "3"}},
+	}
+	for _, test := range tests {
+		terms := TermSet{}
+		if err := json.Unmarshal([]byte(test.JSON), &terms); err != nil {
+			t.Errorf("reading %s: %s", test.JSON, err.Error())
+		} else if !reflect.DeepEqual(terms, test.Expected) {
+			t.Errorf("read %s as %v, expected %v", test.JSON, terms, test.Expected)
+		}
+	}
+	terms := TermSet{}
+	if err := json.Unmarshal([]byte(`"reform"`), &terms); err == nil {
+		t.Errorf("read a string as terms")
+	}
+}
+
+func TestDocumentTerms(t *testing.T) {
+	root := DebateMapRoot{}
+	if err := json.Unmarshal([]byte(`{"nodes": [
+	  {"_key": "R", "type": 40, "children": {"A": {"_": true, "_key": "A", "polarity": 10}},
+	   "current": {"titles": {"base": "Brasil should reform", "allTerms": {"brasil": true, "should": true, "reform": true, "_key": "allTerms"}}}},
+	  {"_key": "A", "type": 50, "children": {"P": {"_": true, "_key": "P"}},
+	   "current": {"argumentType": 20, "titles": {"base": "Deficit", "allTerms": {"deficit": true, "_key": "allTerms"}}}},
+	  {"_key": "P", "type": 40, "current": {"titles": {"base": "The deficit grows", "allTerms": {"_key": "allTerms"}}}}
+	]}`), &root); err != nil {
+		t.Fatalf("reading the export: %s", err.Error())
+	}
+	g := buildGraph(root)
+
+	// The claims and arguments keep the terms of their titles
+	claims := map[string]Claim{}
+	for _, claim := range g.Claims {
+		claims[claim.ID] = claim
+	}
+	if terms := claims["R"].Terms; !reflect.DeepEqual(terms, []string{"brasil", "reform", "should"}) {
+		t.Errorf("R has the terms %v", terms)
+	}
+	if len(g.Arguments) != 1 || !reflect.DeepEqual(g.Arguments[0].Terms, []string{"deficit"}) {
+		t.Errorf("the arguments have the terms %+v", g.Arguments)
+	}
+
+	// A document without terms is written without the field
+	data, err := json.Marshal(claims["P"])
+	if err != nil {
+		t.Fatalf("writing P: %s", err.Error())
+	}
+	if strings.Contains(string(data), `"terms"`) {
+		t.Errorf("P is written with terms: %s", data)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DebateMapUser is the public profile of a Debate Map user, keyed by their Firebase UID.
+// The join date is in the userExtras of the user instead.
+type DebateMapUser struct {
+	ID          string `json:"_key"`
+	DisplayName string `json:"displayName"`
+}
+
+type DebateMapUserExtras struct {
+	ID       string `json:"_key"`
+	JoinDate int64  `json:"joinDate"`
+}
+
+func (extras DebateMapUserExtras) JoinTime() time.Time {
+	return time.Unix(0, extras.JoinDate*1000000)
+}
+
+// A User is someone who wrote claims, arguments or ratings, identified by their Firebase UID.
+// Account is their ID in the Canonical Debate identity system, when known (see --accounts).
+type User struct {
+	Key       string    `json:"_key"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"start"`
+	Name      string    `json:"name"`
+	Account   string    `json:"account,omitempty"`
+}
+
+func (user User) ArangoID() string {
+	return fmt.Sprintf("users/%s", user.Key)
+}
+
+func NewUser(uid string) User {
+	return User{
+		Key: documentKey("users", uid),
+		ID:  uid,
+	}
+}
+
+// A Creation is an edge from a User to a Claim, Argument or Rating they wrote
+type Creation struct {
+	Key       string    `json:"_key"`
+	CreatedAt time.Time `json:"start"`
+	Creator   string    `json:"creator"`
+	From      string    `json:"_from,omitempty"`
+	To        string    `json:"_to,omitempty"`
+}
+
+func NewCreation(fromUser User, toid string, createdAt time.Time) Creation {
+	return Creation{
+		Key:       edgeKey("created", fromUser.ArangoID(), toid),
+		CreatedAt: createdAt,
+		Creator:   fromUser.ID,
+		From:      fromUser.ArangoID(),
+		To:        toid,
+	}
+}
+
+// addUsers adds the users of the export to the graph, and the authors of the claims, arguments and ratings
+// that are not among them (all of them, when the export has no users), with an edge to everything they wrote.
+// A user without a join date starts with the first thing they wrote.
+func addUsers(g *Graph, exportUsers []DebateMapUser, exportExtras []DebateMapUserExtras) {
+	users := map[string]*User{}
+	order := []string{}
+	addUser := func(uid string) *User {
+		if user, ok := users[uid]; ok {
+			return user
+		}
+		user := NewUser(uid)
+		users[uid] = &user
+		order = append(order, uid)
+		return &user
+	}
+	for _, dmu := range exportUsers {
+		if dmu.ID != "" {
+			addUser(dmu.ID).Name = dmu.DisplayName
+		}
+	}
+	fromExport := len(order)
+	for _, extras := range exportExtras {
+		if user, ok := users[extras.ID]; ok && extras.JoinDate != 0 {
+			user.CreatedAt = extras.JoinTime()
+		}
+	}
+
+	type authored struct {
+		Creator   string
+		Handle    string
+		CreatedAt time.Time
+	}
+	created := []authored{}
+	for _, claim := range g.Claims {
+		created = append(created, authored{claim.Creator, claim.ArangoID(), claim.CreatedAt})
+	}
+	for _, arg := range g.Arguments {
+		created = append(created, authored{arg.Creator, arg.ArangoID(), arg.CreatedAt})
+	}
+	for _, rating := range g.Ratings {
+		created = append(created, authored{rating.Creator, rating.ArangoID(), rating.CreatedAt})
+	}
+	joined := map[string]bool{}
+	for uid, user := range users {
+		joined[uid] = !user.CreatedAt.IsZero()
+	}
+	for _, doc := range created {
+		if doc.Creator == "" {
+			continue
+		}
+		user := addUser(doc.Creator)
+		if !joined[doc.Creator] && (user.CreatedAt.IsZero() || doc.CreatedAt.Before(user.CreatedAt)) {
+			user.CreatedAt = doc.CreatedAt
+		}
+		g.Creations = append(g.Creations, NewCreation(*user, doc.Handle, doc.CreatedAt))
+	}
+
+	for _, uid := range order {
+		g.Users = append(g.Users, *users[uid])
+	}
+	if fromExport > 0 && len(order) > fromExport {
+		fmt.Fprintf(logOutput, "----------------------------%d authors are not among the users of the export\n", len(order)-fromExport)
+	}
+}
+
+// loadAccounts reads the CSV file mapping the Firebase UIDs of the users to their accounts,
+// with the columns "uid" and "account"
+func loadAccounts(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err.Error())
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	uidColumn, ok := columns["uid"]
+	accountColumn, ok2 := columns["account"]
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("%s: the header must name the columns uid and account, found %s", filename, strings.Join(header, ", "))
+	}
+
+	accounts := map[string]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err.Error())
+		}
+		uid := strings.TrimSpace(record[uidColumn])
+		account := strings.TrimSpace(record[accountColumn])
+		if uid == "" || account == "" {
+			continue
+		}
+		if previous, ok := accounts[uid]; ok && previous != account {
+			line, _ := reader.FieldPos(uidColumn)
+			return nil, fmt.Errorf("%s:%d: user %s is mapped to both %s and %s", filename, line, uid, previous, account)
+		}
+		accounts[uid] = account
+	}
+	return accounts, nil
+}
+
+// linkAccounts sets the account of each user found in the mapping, and reports the users left without one
+func linkAccounts(g *Graph, accounts map[string]string) {
+	linked := 0
+	unmapped := []string{}
+	for i, user := range g.Users {
+		if account, ok := accounts[user.ID]; ok {
+			g.Users[i].Account = account
+			linked++
+		} else {
+			unmapped = append(unmapped, user.ID)
+		}
+	}
+	sort.Strings(unmapped)
+	for _, uid := range unmapped {
+		fmt.Fprintln(logOutput, "----------------------------No account for user", uid)
+	}
+	fmt.Fprintf(logOutput, "Linked %d of %d users to their accounts\n", linked, len(g.Users))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddUsers(t *testing.T) {
+	g := testGraph(t)
+
+	// The users of the export come first, then the authors missing from it
+	ids := []string{}
+	users := map[string]User{}
+	for _, user := range g.Users {
+		ids = append(ids, user.ID)
+		users[user.ID] = user
+	}
+	if !reflect.DeepEqual(ids, []string{"u1", "u2", "u3"}) || users["u1"].Name != "Ana" || users["u2"].Name != "Bruno" || users["u3"].Name != "" {
+		t.Errorf("added the users %+v", g.Users)
+	}
+	// Ana joined before she wrote anything; the others start with the first thing they wrote
+	tests := []struct {
+		ID    string
+		Start int64
+	}{
+		{"u1", 1552330000000},
+		{"u2", 1552331700000},
+		{"u3", 1552332100000},
+	}
+	for _, test := range tests {
+		if start := users[test.ID].CreatedAt; !start.Equal(time.Unix(0, test.Start*1000000)) {
+			t.Errorf("%s starts on %s, expected %s", test.ID, start, time.Unix(0, test.Start*1000000))
+		}
+	}
+
+	// One created edge from its author to every claim, argument and rating that has one
+	authors := map[string]string{}
+	for _, claim := range g.Claims {
+		authors[claim.ArangoID()] = claim.Creator
+	}
+	for _, arg := range g.Arguments {
+		authors[arg.ArangoID()] = arg.Creator
+	}
+	for _, rating := range g.Ratings {
+		authors[rating.ArangoID()] = rating.Creator
+	}
+	expected := 0
+	for _, creator := range authors {
+		if creator != "" {
+			expected++
+		}
+	}
+	if len(g.Creations) != expected {
+		t.Errorf("added %d created edges, expected %d", len(g.Creations), expected)
+	}
+	for _, creation := range g.Creations {
+		creator := authors[creation.To]
+		if creator == "" || creation.Creator != creator || creation.From != users[creator].ArangoID() {
+			t.Errorf("the created edge %s goes from %s to %s, written by %q", creation.Key, creation.From, creation.To, creator)
+		}
+	}
+}
+
+func TestAddUsersWithoutExportUsers(t *testing.T) {
+	// Without users in the export, the users are the authors
+	g := &Graph{}
+	for _, node := range []DebateMapNode{{ID: "c1", Creator: "u2", CreatedAt: 2000}, {ID: "c2", Creator: "u1", CreatedAt: 3000}, {ID: "c3", Creator: "u2", CreatedAt: 1000}, {ID: "c4"}} {
+		g.Claims = append(g.Claims, NewClaim(node))
+	}
+	addUsers(g, nil, nil)
+	if len(g.Users) != 2 || g.Users[0].ID != "u2" || g.Users[1].ID != "u1" || len(g.Creations) != 3 {
+		t.Fatalf("added the users %+v and %d created edges, expected u2 and u1 and 3 edges", g.Users, len(g.Creations))
+	}
+	if start := g.Users[0].CreatedAt; !start.Equal(time.Unix(0, 1000*1000000)) {
+		t.Errorf("u2 starts on %s, expected the date of c3", start)
+	}
+}
+
+func TestLinkAccounts(t *testing.T) {
+	g := testGraph(t)
+	linkAccounts(g, map[string]string{"u1": "ana@example.org", "u3": "carla@example.org", "u9": "nobody@example.org"})
+	accounts := map[string]string{}
+	for _, user := range g.Users {
+		accounts[user.ID] = user.Account
+	}
+	if !reflect.DeepEqual(accounts, map[string]string{"u1": "ana@example.org", "u2": "", "u3": "carla@example.org"}) {
+		t.Errorf("linked the accounts %v", accounts)
+	}
+}
+
+func writeAccounts(t *testing.T, data string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "accounts.csv")
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatalf("writing the accounts: %s", err.Error())
+	}
+	return filename
+}
+
+func TestLoadAccounts(t *testing.T) {
+	// The columns can be in any order and case, among others; rows without a uid or account are skipped
+	accounts, err := loadAccounts(writeAccounts(t, "Account, name, UID\nana@example.org, Ana, u1\n, Bruno, u2\ncarla@example.org, Carla, u3\nana@example.org, Ana, u1\n"))
+	if err != nil {
+		t.Fatalf("loadAccounts: %s", err.Error())
+	}
+	if !reflect.DeepEqual(accounts, map[string]string{"u1": "ana@example.org", "u3": "carla@example.org"}) {
+		t.Errorf("read the accounts %v", accounts)
+	}
+
+	tests := []struct {
+		Name  string
+		Data  string
+		Error string
+	}{
+		{"no account column", "uid,email\nu1,ana@example.org\n", "the header must name the columns uid and account"},
+		{"a missing field", "uid,account\nu1,ana@example.org\nu2\n", "wrong number of fields"},
+		{"an unterminated quote", "uid,account\nu1,\"ana@example.org\n", "extraneous or missing \""},
+		{"two accounts for a user", "uid,account\nu1,ana@example.org\nu2,bruno@example.org\nu1,other@example.org\n", ":4: user u1 is mapped to both ana@example.org and other@example.org"},
+		{"an empty file", "", "EOF"},
+	}
+	for _, test := range tests {
+		if _, err := loadAccounts(writeAccounts(t, test.Data)); err == nil || !strings.Contains(err.Error(), test.Error) {
+			t.Errorf("loadAccounts with %s returned the error %v, expected %q", test.Name, err, test.Error)
+		}
+	}
+	if _, err := loadAccounts(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Errorf("loadAccounts read a missing file")
+	}
+}